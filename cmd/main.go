@@ -36,7 +36,11 @@ func main() {
 	}
 	defer shutdown()
 
-	router := config.NewRouter(db.Pool)
+	// Background jobs started by the router stop with this context
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	router := config.NewRouter(jobsCtx, db.Pool)
 
 	srv := &http.Server{
 		Addr:    constants.PORT,
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/handler"
+)
+
+// NewTokenCookies reads AUTH_COOKIES, "true" has logins and refreshes set
+// the tokens as cookies for browser clients instead of returning them. The
+// cookies are scoped by AUTH_COOKIE_DOMAIN and AUTH_COOKIE_PATH, with
+// AUTH_COOKIE_SAMESITE lax (default), strict or none. It returns nil when
+// the mode is off.
+func NewTokenCookies(accessTTL, refreshTTL time.Duration) *handler.TokenCookies {
+	if os.Getenv("AUTH_COOKIES") != "true" {
+		return nil
+	}
+
+	path := os.Getenv("AUTH_COOKIE_PATH")
+	if path == "" {
+		path = "/"
+	}
+
+	var sameSite http.SameSite
+	switch mode := strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")); mode {
+	case "", "lax":
+		sameSite = http.SameSiteLaxMode
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	default:
+		log.Panicf("Unknown AUTH_COOKIE_SAMESITE %q, use lax, strict or none", mode)
+	}
+
+	return &handler.TokenCookies{
+		Domain:     os.Getenv("AUTH_COOKIE_DOMAIN"),
+		Path:       path,
+		SameSite:   sameSite,
+		AccessTTL:  accessTTL,
+		RefreshTTL: refreshTTL,
+	}
+}
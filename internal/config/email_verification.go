@@ -0,0 +1,24 @@
+package config
+
+import (
+	"log"
+	"os"
+
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+)
+
+// EmailVerificationMode reads EMAIL_VERIFICATION, what unverified users may
+// do: "allow" (default), "restrict" or "block".
+func EmailVerificationMode() service.EmailVerificationMode {
+	mode := service.EmailVerificationMode(os.Getenv("EMAIL_VERIFICATION"))
+
+	switch mode {
+	case service.EmailVerificationRestrict, service.EmailVerificationBlock:
+		return mode
+	case "", service.EmailVerificationAllow:
+		return service.EmailVerificationAllow
+	default:
+		log.Panicf("Unknown EMAIL_VERIFICATION %q, use allow, restrict or block", mode)
+		return ""
+	}
+}
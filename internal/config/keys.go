@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+)
+
+// NewKeyRing loads the signing keys configured under the given prefix
+// (ACCESS or REFRESH) into a key ring:
+//
+//   - <PREFIX>_SECRET: HMAC secret, <PREFIX>_KEY_ID optionally names it
+//   - <PREFIX>_PREVIOUS_SECRETS: comma separated HMAC secrets being retired
+//   - <PREFIX>_PRIVATE_KEY: inline PEM private key, named by <PREFIX>_KEY_ID
+//   - <PREFIX>_PRIVATE_KEY_FILE: comma separated PEM files, named after the file
+//   - <PREFIX>_KEYS_DIR: directory of *.pem files, named after the file
+//
+// <PREFIX>_ACTIVE_KEY_ID picks the signing key, it may be omitted when only one
+// private key (or only the secret) is configured. Every other key keeps
+// verifying tokens for <PREFIX>_KEY_GRACE, which defaults to the token TTL,
+// from its retirement time in <PREFIX>_RETIRED_AT: comma separated kid=RFC 3339
+// pairs, a time without kid applies to the retired keys not listed.
+func NewKeyRing(prefix string, ttl time.Duration) (*service.KeyRing, error) {
+	grace := ttl
+	if value := os.Getenv(prefix + "_KEY_GRACE"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s_KEY_GRACE: %w", prefix, err)
+		}
+		grace = parsed
+	}
+
+	ring := service.NewKeyRing(grace)
+	if err := ReloadKeyRing(prefix, ring); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// ReloadKeyRing reads the keys again and swaps them into the ring.
+func ReloadKeyRing(prefix string, ring *service.KeyRing) error {
+	active, retired, err := loadKeys(prefix)
+	if err != nil {
+		return err
+	}
+
+	ring.Load(active, retired...)
+	return nil
+}
+
+// WatchKeyRings reloads the rings whenever the process receives SIGHUP, until
+// ctx is done. A failed reload keeps the previous keys.
+func WatchKeyRings(ctx context.Context, rings map[string]*service.KeyRing) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			for prefix, ring := range rings {
+				if err := ReloadKeyRing(prefix, ring); err != nil {
+					log.Printf("KEY RING: failed to reload %s keys: %s", prefix, err.Error())
+					continue
+				}
+				log.Printf("KEY RING: %s keys reloaded, signing with %s", prefix, ring.KeyID())
+			}
+		}
+	}
+}
+
+func loadKeys(prefix string) (service.Signer, []service.RetiredKey, error) {
+	var (
+		secret     service.Signer
+		privateKey []service.Signer
+		previous   []service.Signer
+	)
+
+	if value := os.Getenv(prefix + "_SECRET"); value != "" {
+		secret = service.NewHMACSigner(os.Getenv(prefix+"_KEY_ID"), value)
+	}
+
+	for _, value := range splitList(os.Getenv(prefix + "_PREVIOUS_SECRETS")) {
+		previous = append(previous, service.NewHMACSigner("", value))
+	}
+
+	if value := os.Getenv(prefix + "_PRIVATE_KEY"); value != "" {
+		signer, err := service.NewSignerFromPEM(os.Getenv(prefix+"_KEY_ID"), []byte(value))
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s_PRIVATE_KEY: %w", prefix, err)
+		}
+		privateKey = append(privateKey, signer)
+	}
+
+	files := splitList(os.Getenv(prefix + "_PRIVATE_KEY_FILE"))
+	if dir := os.Getenv(prefix + "_KEYS_DIR"); dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		signer, err := service.NewSignerFromPEM(kid, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		privateKey = append(privateKey, signer)
+	}
+
+	all := append(privateKey, previous...)
+	if secret != nil {
+		all = append(all, secret)
+	}
+
+	if len(all) == 0 {
+		return nil, nil, fmt.Errorf("no %s signing key configured", prefix)
+	}
+
+	activeID := os.Getenv(prefix + "_ACTIVE_KEY_ID")
+	if activeID == "" {
+		switch {
+		case len(privateKey) == 1:
+			activeID = privateKey[0].KeyID()
+		case len(privateKey) == 0 && secret != nil:
+			activeID = secret.KeyID()
+		default:
+			return nil, nil, fmt.Errorf("%s_ACTIVE_KEY_ID must be set when several keys are configured", prefix)
+		}
+	}
+
+	retiredAt, err := parseRetiredAt(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		active  service.Signer
+		retired []service.RetiredKey
+	)
+
+	for _, signer := range all {
+		if signer.KeyID() == activeID && active == nil {
+			active = signer
+			continue
+		}
+
+		// Taken from the configuration, a restart must not start the grace over
+		at, ok := retiredAt[signer.KeyID()]
+		if !ok {
+			at, ok = retiredAt[""]
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("%s_RETIRED_AT must give when key %q was retired", prefix, signer.KeyID())
+		}
+		retired = append(retired, service.RetiredKey{Signer: signer, RetiredAt: at})
+	}
+
+	if active == nil {
+		return nil, nil, fmt.Errorf("%s_ACTIVE_KEY_ID %q does not match any key", prefix, activeID)
+	}
+
+	return active, retired, nil
+}
+
+// parseRetiredAt reads <PREFIX>_RETIRED_AT by kid, the default is under "".
+func parseRetiredAt(prefix string) (map[string]time.Time, error) {
+	retiredAt := make(map[string]time.Time)
+
+	for _, item := range splitList(os.Getenv(prefix + "_RETIRED_AT")) {
+		kid, value, ok := strings.Cut(item, "=")
+		if !ok {
+			kid, value = "", item
+		}
+
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s_RETIRED_AT: %w", prefix, err)
+		}
+		retiredAt[strings.TrimSpace(kid)] = at
+	}
+
+	return retiredAt, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+)
+
+// NewMailSender picks the mail backend from MAIL_DRIVER, "smtp" or "log"
+// (default) which only prints the messages.
+func NewMailSender() mail.Sender {
+	if os.Getenv("MAIL_DRIVER") != "smtp" {
+		return mail.NewLogSender()
+	}
+
+	port, exists := os.LookupEnv("SMTP_PORT")
+	if !exists {
+		port = "587"
+	}
+
+	from, exists := os.LookupEnv("MAIL_FROM")
+	if !exists {
+		from = "no-reply@localhost"
+	}
+
+	return mail.NewSMTPSender(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+// AppURL is where the frontend lives, links sent by email point there.
+func AppURL() string {
+	url, exists := os.LookupEnv("APP_URL")
+	if !exists {
+		return "http://localhost:3000"
+	}
+
+	return url
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"os"
+
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// IssuerURL is the public URL of the API, the iss of ID tokens and the base
+// of the endpoints listed in the OpenID Connect discovery document.
+func IssuerURL() string {
+	url, exists := os.LookupEnv("ISSUER_URL")
+	if !exists {
+		return "http://localhost" + constants.PORT
+	}
+
+	return url
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/leonardonicola/golerplate/pkg/util"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NewPasswordHasher hashes new passwords with PASSWORD_HASHER, "argon2id"
+// (default) or "bcrypt", tuned by ARGON2_MEMORY (KiB), ARGON2_ITERATIONS,
+// ARGON2_PARALLELISM and BCRYPT_COST. Hashes made by the other algorithm or
+// other parameters still verify, and are replaced on the next login.
+func NewPasswordHasher() util.PasswordHasher {
+	argon := util.DefaultArgon2idParams
+	argon.Memory = uint32(envInt("ARGON2_MEMORY", int(argon.Memory)))
+	argon.Iterations = uint32(envInt("ARGON2_ITERATIONS", int(argon.Iterations)))
+	argon.Parallelism = uint8(envInt("ARGON2_PARALLELISM", int(argon.Parallelism)))
+
+	argonHasher := util.NewArgon2idHasher(argon)
+	bcryptHasher := util.NewBcryptHasher(envInt("BCRYPT_COST", bcrypt.DefaultCost))
+
+	switch algorithm := os.Getenv("PASSWORD_HASHER"); algorithm {
+	case "", "argon2id":
+		return util.NewUpgradingHasher(argonHasher, bcryptHasher)
+	case "bcrypt":
+		return util.NewUpgradingHasher(bcryptHasher, argonHasher)
+	default:
+		log.Panicf("Unknown PASSWORD_HASHER %q, use argon2id or bcrypt", algorithm)
+		return nil
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Panicf("Invalid %s %q, expected a positive integer", key, value)
+	}
+
+	return n
+}
+
+// NewPasswordPolicy reads PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH and
+// PASSWORD_MIN_CLASSES over the defaults. BREACHED_PASSWORDS_FILE, when set,
+// points at the Have I Been Pwned SHA-1 list ordered by hash, or at a
+// directory of its range files.
+func NewPasswordPolicy() *util.PasswordPolicy {
+	policy := util.DefaultPasswordPolicy
+	policy.MinLength = envInt("PASSWORD_MIN_LENGTH", policy.MinLength)
+	policy.MaxLength = envInt("PASSWORD_MAX_LENGTH", policy.MaxLength)
+	policy.MinClasses = envInt("PASSWORD_MIN_CLASSES", policy.MinClasses)
+
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		breached, err := util.OpenBreachedPasswords(path)
+		if err != nil {
+			log.Panicf("Failed to open BREACHED_PASSWORDS_FILE: %s", err.Error())
+		}
+		policy.Breached = breached
+	}
+
+	return &policy
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+)
+
+// BootstrapAdmin grants the admin role to the user registered with
+// BOOTSTRAP_ADMIN_EMAIL, as long as nobody holds it yet. Later admins are
+// managed through /api/admin/users/{userId}/roles.
+func BootstrapAdmin(ctx context.Context, us service.UserService, rs service.RBACService) {
+	email := os.Getenv("BOOTSTRAP_ADMIN_EMAIL")
+	if email == "" {
+		return
+	}
+
+	user, err := us.GetByEmail(ctx, email)
+	if err != nil {
+		log.Printf("Admin bootstrap skipped, %s: %v", email, err)
+		return
+	}
+
+	granted, err := rs.BootstrapAdmin(ctx, user.ID)
+	if err != nil {
+		log.Printf("Admin bootstrap failed: %v", err)
+		return
+	}
+
+	if granted {
+		log.Printf("Admin role granted to %s", email)
+	}
+}
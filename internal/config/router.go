@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/leonardonicola/golerplate/docs"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
 	"github.com/leonardonicola/golerplate/internal/domain/service"
 	"github.com/leonardonicola/golerplate/internal/handler"
 	"github.com/leonardonicola/golerplate/internal/infra/repository"
@@ -17,7 +19,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewRouter(pool *pgxpool.Pool) *gin.Engine {
+func NewRouter(ctx context.Context, pool *pgxpool.Pool) *gin.Engine {
 
 	r := gin.New()
 
@@ -25,32 +27,154 @@ func NewRouter(pool *pgxpool.Pool) *gin.Engine {
 	r.Use(gin.Logger())
 	r.Use(middleware.TracingMiddleware())
 
+	// Security events.
+	securityEventRepo := repository.NewSecurityEventRepository(pool)
+	securityEventService := service.NewSecurityEventService(securityEventRepo)
+	securityEventHandler := handler.NewSecurityEventHandler(securityEventService)
+
 	// User.
 	userRepo := repository.NewUserRepository(pool)
-	userService := service.NewUserService(userRepo)
-	userHandler := handler.NewUserHandler(userService)
+	userService := service.NewUserService(userRepo, NewPasswordHasher(), NewPasswordPolicy())
+	mailer := NewMailSender()
+
+	// Email verification.
+	emailVerificationRepo := repository.NewEmailVerificationRepository(pool)
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepo, userService, mailer, AppURL()+"/verify-email")
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationService)
+
+	userHandler := handler.NewUserHandler(userService, emailVerificationService, securityEventService)
 
 	// Auth.
-	accessSecret, accessExists := os.LookupEnv("ACCESS_SECRET")
-	refreshSecret, refreshExists := os.LookupEnv("REFRESH_SECRET")
-	if !accessExists || !refreshExists {
-		log.Panic("SECRETS variables are not defined correctly.")
+	accessTTL, refreshTTL := time.Hour, 2*time.Hour
+
+	accessSigner, err := NewKeyRing("ACCESS", accessTTL)
+	if err != nil {
+		log.Panicf("Failed to load access signing keys: %v", err)
+	}
+	refreshSigner, err := NewKeyRing("REFRESH", refreshTTL)
+	if err != nil {
+		log.Panicf("Failed to load refresh signing keys: %v", err)
+	}
+	go WatchKeyRings(ctx, map[string]*service.KeyRing{
+		"ACCESS":  accessSigner,
+		"REFRESH": refreshSigner,
+	})
+
+	// Browser clients may get the tokens as cookies
+	tokenCookies := NewTokenCookies(accessTTL, refreshTTL)
+
+	refreshTokenRepo := repository.NewRefreshTokenRepository(pool)
+	revocationStore := newRevocationStore(pool)
+	go repository.PurgeRevocations(ctx, revocationStore, 10*time.Minute)
+
+	// Session.
+	sessionRepo := repository.NewSessionRepository(pool)
+	sessionService := service.NewSessionService(sessionRepo, refreshTokenRepo, revocationStore, accessTTL, refreshTTL)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+
+	// RBAC.
+	rbacRepo := repository.NewRBACRepository(pool)
+	rbacService := service.NewRBACService(rbacRepo)
+	rbacHandler := handler.NewRBACHandler(rbacService)
+	rbacMiddleware := middleware.NewRBACMiddleware(rbacService)
+	BootstrapAdmin(ctx, userService, rbacService)
+
+	authService := service.NewAuthService(accessSigner, refreshSigner, accessTTL, refreshTTL, refreshTokenRepo, revocationStore, sessionService, rbacService, userService, EmailVerificationMode())
+
+	// MFA.
+	mfaIssuer, exists := os.LookupEnv("MFA_ISSUER")
+	if !exists {
+		mfaIssuer = "Golerplate"
+	}
+	mfaRepo := repository.NewMFARepository(pool)
+	mfaService := service.NewMFAService(mfaRepo, userService, mfaIssuer)
+	mfaHandler := handler.NewMFAHandler(userService, mfaService)
+
+	// Login throttling.
+	loginAttemptStore := newLoginAttemptStore(pool)
+	go repository.PurgeLoginAttempts(ctx, loginAttemptStore, 24*time.Hour, 10*time.Minute)
+	loginThrottle := service.NewLoginThrottleService(loginAttemptStore, service.DefaultAccountThrottle, service.DefaultIPThrottle)
+
+	authHandler := handler.NewAuthHandler(userService, authService, mfaService, loginThrottle, securityEventService, tokenCookies)
+
+	// Password reset.
+	passwordResetRepo := repository.NewPasswordResetRepository(pool)
+	passwordResetService := service.NewPasswordResetService(passwordResetRepo, userService, authService, mailer, AppURL()+"/reset-password")
+	passwordHandler := handler.NewPasswordHandler(passwordResetService, userService, authService, securityEventService)
+
+	// Magic links.
+	magicLinkService := service.NewMagicLinkService(userService, refreshSigner, revocationStore, mailer, AppURL()+"/login/link", os.Getenv("MAGIC_LINK_BIND_USER_AGENT") == "true")
+	magicLinkHandler := handler.NewMagicLinkHandler(magicLinkService, authService, mfaService, loginThrottle, securityEventService, tokenCookies)
+
+	// Passkeys.
+	webAuthn, err := NewWebAuthn()
+	if err != nil {
+		log.Panicf("Failed to configure WebAuthn: %v", err)
 	}
+	webAuthnRepo := repository.NewWebAuthnRepository(pool)
+	passkeyService := service.NewPasskeyService(webAuthn, webAuthnRepo, userService, refreshSigner, revocationStore)
+	passkeyHandler := handler.NewPasskeyHandler(userService, authService, passkeyService, securityEventService, tokenCookies)
+
+	// Federated login.
+	identityRepo := repository.NewIdentityRepository(pool)
+	federationService := service.NewFederationService(NewIdentityProviders(), identityRepo, userService, refreshSigner, revocationStore)
+	federationHandler := handler.NewFederationHandler(federationService, authService, mfaService, loginThrottle, securityEventService, tokenCookies)
+
+	// API keys.
+	apiKeyRepo := repository.NewAPIKeyRepository(pool)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+
+	// OAuth.
+	oauthClientRepo := repository.NewOAuthClientRepository(pool)
+	oauthClientService := service.NewOAuthClientService(oauthClientRepo)
+	oauthClientHandler := handler.NewOAuthClientHandler(oauthClientService)
+	oauthCodeRepo := repository.NewOAuthAuthorizationCodeRepository(pool)
+	oidcService := service.NewOIDCService(accessSigner, IssuerURL(), accessTTL, userService)
+	oidcHandler := handler.NewOIDCHandler(oidcService)
+	oauthService := service.NewOAuthService(oauthClientRepo, oauthCodeRepo, userService, authService, oidcService, accessTTL)
+	introspectionService := service.NewIntrospectionService(accessSigner, refreshSigner, authService, refreshTokenRepo, sessionRepo, apiKeyService)
+	oauthHandler := handler.NewOAuthHandler(oauthClientService, oauthService, introspectionService, securityEventService, AppURL()+"/oauth/authorize")
 
-	authService := service.NewAuthService(accessSecret, refreshSecret, time.Hour, 2*time.Hour)
-	authHandler := handler.NewAuthHandler(userService, authService)
+	// Impersonation.
+	impersonationAuditRepo := repository.NewImpersonationAuditRepository(pool)
+	impersonationService := service.NewImpersonationService(impersonationAuditRepo, userService, authService)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationService, securityEventService)
+	impersonationMiddleware := middleware.NewImpersonationMiddleware(impersonationService)
 
-	jwtMiddleware := middleware.NewJWTAuthMiddleware(accessSecret)
+	jwtMiddleware := middleware.NewJWTAuthMiddleware(accessSigner, authService, apiKeyService)
+	keysHandler := handler.NewKeysHandler(accessSigner)
+
+	r.GET("/.well-known/jwks.json", keysHandler.JWKS)
+	// OpenID Connect is off with an HMAC access token key, see OIDCService
+	if oidcService.Enabled() {
+		r.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+	}
 
 	docs.SwaggerInfo.BasePath = "/api"
 	public := r.Group("/api")
 	{
 		public.POST("/register", userHandler.Register)
 		public.POST("/login", authHandler.Login)
-		public.POST("/refresh", authHandler.Refresh)
+		public.POST("/login/mfa", authHandler.LoginMFA)
+		public.POST("/login/link", magicLinkHandler.Send)
+		public.POST("/login/link/consume", magicLinkHandler.Consume)
+		public.POST("/login/passkey/begin", passkeyHandler.BeginLogin)
+		public.POST("/login/passkey/finish", passkeyHandler.FinishLogin)
+		public.GET("/login/sso", federationHandler.Providers)
+		public.POST("/login/sso/finish", federationHandler.FinishLogin)
+		public.POST("/login/sso/:provider", federationHandler.BeginLogin)
+		public.POST("/refresh", middleware.CSRF(), authHandler.Refresh)
+		public.POST("/password/forgot", passwordHandler.Forgot)
+		public.POST("/password/reset", passwordHandler.Reset)
+		public.POST("/email/verify", emailVerificationHandler.Verify)
+		public.POST("/email/verify/resend", emailVerificationHandler.Resend)
+		public.GET("/oauth/authorize", oauthHandler.Authorize)
+		public.POST("/oauth/token", oauthHandler.Token)
+		public.POST("/oauth/introspect", oauthHandler.Introspect)
 	}
 
-	protected := r.Group("/api", jwtMiddleware.AuthRequired())
+	protected := r.Group("/api", middleware.CSRF(), jwtMiddleware.AuthRequired(), impersonationMiddleware.Audit())
 	{
 		protected.GET("/docs/*any", func(c *gin.Context) {
 			if c.Param("any") == "/" || c.Param("any") == "" {
@@ -60,7 +184,111 @@ func NewRouter(pool *pgxpool.Pool) *gin.Engine {
 
 			ginSwagger.WrapHandler(swaggerFiles.Handler)(c)
 		})
+
+		// Reached by OAuth clients, with the tokens users granted them
+		if oidcService.Enabled() {
+			protected.GET("/oauth/userinfo", oidcHandler.UserInfo)
+			protected.POST("/oauth/userinfo", oidcHandler.UserInfo)
+		}
+	}
+
+	// Account security routes can't be reached with an API key or by OAuth clients
+	account := protected.Group("", middleware.InteractiveOnly())
+	{
+		account.POST("/logout", authHandler.Logout)
+
+		account.GET("/sessions", sessionHandler.List)
+		account.PATCH("/sessions/:id", sessionHandler.Rename)
+
+		account.GET("/me/security-events", securityEventHandler.Mine)
+	}
+
+	// Changing how the user logs in, creating credentials or signing sessions out
+	// needs a login, or a /reauth, this recent. There is no route to change
+	// the email or delete the account yet, they belong behind it when added.
+	recentAuth := middleware.RequireRecentAuth(5 * time.Minute)
+
+	// Support impersonating the user can look around, not change how the user
+	// logs in or leave credentials behind
+	sensitive := account.Group("", middleware.NoImpersonation())
+	{
+		sensitive.POST("/reauth", authHandler.Reauth)
+		sensitive.POST("/logout-all", recentAuth, authHandler.LogoutAll)
+		sensitive.POST("/password/change", recentAuth, passwordHandler.Change)
+
+		sensitive.DELETE("/sessions/:id", sessionHandler.Revoke)
+	}
+
+	// Unverified users in restricted mode can only manage their sessions
+	verified := sensitive.Group("", middleware.VerifiedEmailOnly())
+	{
+		verified.POST("/mfa/totp/enroll", mfaHandler.Enroll)
+		verified.POST("/mfa/totp/confirm", mfaHandler.Confirm)
+		verified.POST("/mfa/totp/disable", recentAuth, mfaHandler.Disable)
+
+		verified.GET("/passkeys", passkeyHandler.List)
+		verified.POST("/passkeys/register/begin", recentAuth, passkeyHandler.BeginRegistration)
+		verified.POST("/passkeys/register/finish", recentAuth, passkeyHandler.FinishRegistration)
+		verified.DELETE("/passkeys/:id", recentAuth, passkeyHandler.Delete)
+
+		verified.GET("/tokens", apiKeyHandler.List)
+		verified.POST("/tokens", recentAuth, apiKeyHandler.Create)
+		verified.DELETE("/tokens/:id", apiKeyHandler.Revoke)
+
+		verified.GET("/identities", federationHandler.List)
+		verified.POST("/identities/finish", recentAuth, federationHandler.FinishLink)
+		verified.POST("/identities/:provider", recentAuth, federationHandler.BeginLink)
+		verified.DELETE("/identities/:id", federationHandler.Unlink)
+
+		verified.POST("/oauth/authorize", oauthHandler.Approve)
+	}
+
+	admin := protected.Group("/admin", middleware.VerifiedEmailOnly())
+	{
+		admin.DELETE("/users/:userId/lockout", rbacMiddleware.RequirePermission(entity.PermUsersWrite), authHandler.Unlock)
+		admin.POST("/users/:userId/disable", rbacMiddleware.RequirePermission(entity.PermUsersWrite), authHandler.Disable)
+		admin.POST("/users/:userId/enable", rbacMiddleware.RequirePermission(entity.PermUsersWrite), authHandler.Enable)
+
+		admin.POST("/impersonate/:userId", middleware.InteractiveOnly(), rbacMiddleware.RequirePermission(entity.PermUsersImpersonate), impersonationHandler.Impersonate)
+		admin.GET("/users/:userId/impersonations", rbacMiddleware.RequirePermission(entity.PermUsersRead), impersonationHandler.History)
+
+		admin.GET("/security-events", rbacMiddleware.RequirePermission(entity.PermSecurityEventsRead), securityEventHandler.List)
+
+		admin.GET("/users/:userId/sessions", rbacMiddleware.RequirePermission(entity.PermSessionsRead), sessionHandler.AdminList)
+		admin.DELETE("/users/:userId/sessions", rbacMiddleware.RequirePermission(entity.PermSessionsWrite), recentAuth, sessionHandler.AdminRevokeAll)
+		admin.DELETE("/users/:userId/sessions/:id", rbacMiddleware.RequirePermission(entity.PermSessionsWrite), recentAuth, sessionHandler.AdminRevoke)
+
+		admin.GET("/roles", rbacMiddleware.RequirePermission(entity.PermRolesRead), rbacHandler.ListRoles)
+		admin.GET("/users/:userId/roles", rbacMiddleware.RequirePermission(entity.PermRolesRead), rbacHandler.UserRoles)
+		admin.POST("/users/:userId/roles", rbacMiddleware.RequirePermission(entity.PermRolesWrite), rbacHandler.AssignRole)
+		admin.DELETE("/users/:userId/roles/:role", rbacMiddleware.RequirePermission(entity.PermRolesWrite), rbacHandler.RemoveRole)
+
+		admin.GET("/oauth/clients", rbacMiddleware.RequirePermission(entity.PermClientsRead), oauthClientHandler.List)
+		admin.POST("/oauth/clients", rbacMiddleware.RequirePermission(entity.PermClientsWrite), oauthClientHandler.Create)
+		admin.DELETE("/oauth/clients/:id", rbacMiddleware.RequirePermission(entity.PermClientsWrite), oauthClientHandler.Revoke)
 	}
 
 	return r
 }
+
+// newRevocationStore picks the revocation backend, "memory" only fits a
+// single instance deployment.
+func newRevocationStore(pool *pgxpool.Pool) repository.RevocationStore {
+	switch os.Getenv("REVOCATION_STORE") {
+	case "memory":
+		return repository.NewMemoryRevocationStore()
+	default:
+		return repository.NewRevocationStore(pool)
+	}
+}
+
+// newLoginAttemptStore picks where failed logins are counted, "memory" only
+// fits a single instance deployment.
+func newLoginAttemptStore(pool *pgxpool.Pool) repository.LoginAttemptStore {
+	switch os.Getenv("LOGIN_ATTEMPT_STORE") {
+	case "memory":
+		return repository.NewMemoryLoginAttemptStore()
+	default:
+		return repository.NewLoginAttemptStore(pool)
+	}
+}
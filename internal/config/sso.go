@@ -0,0 +1,44 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/leonardonicola/golerplate/internal/infra/sso"
+)
+
+// NewIdentityProviders reads the providers named in the comma separated
+// SSO_PROVIDERS. Each one is configured by SSO_<NAME>_ISSUER,
+// SSO_<NAME>_CLIENT_ID, SSO_<NAME>_CLIENT_SECRET and the comma separated
+// SSO_<NAME>_SCOPES, "openid,email,profile" by default. Providers send the
+// browser back to APP_URL/login/sso/callback.
+func NewIdentityProviders() []sso.Provider {
+	var providers []sso.Provider
+
+	for _, name := range splitList(os.Getenv("SSO_PROVIDERS")) {
+		prefix := "SSO_" + strings.ToUpper(name) + "_"
+
+		issuer := os.Getenv(prefix + "ISSUER")
+		clientID := os.Getenv(prefix + "CLIENT_ID")
+		if issuer == "" || clientID == "" {
+			log.Panicf("Identity provider %s needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+
+		scopes := splitList(os.Getenv(prefix + "SCOPES"))
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+
+		providers = append(providers, sso.NewProvider(sso.Config{
+			Name:         name,
+			Issuer:       issuer,
+			ClientID:     clientID,
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       scopes,
+			RedirectURL:  AppURL() + "/login/sso/callback",
+		}))
+	}
+
+	return providers
+}
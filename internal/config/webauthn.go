@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// NewWebAuthn configures the passkey relying party from WEBAUTHN_RP_ID,
+// WEBAUTHN_RP_NAME and the comma separated WEBAUTHN_RP_ORIGINS, defaulting
+// to a local setup.
+func NewWebAuthn() (*webauthn.WebAuthn, error) {
+	rpID, exists := os.LookupEnv("WEBAUTHN_RP_ID")
+	if !exists {
+		rpID = "localhost"
+	}
+
+	rpName, exists := os.LookupEnv("WEBAUTHN_RP_NAME")
+	if !exists {
+		rpName = "Golerplate"
+	}
+
+	origins := splitList(os.Getenv("WEBAUTHN_RP_ORIGINS"))
+	if len(origins) == 0 {
+		origins = []string{"http://localhost:3000"}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+	})
+}
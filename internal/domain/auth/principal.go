@@ -0,0 +1,76 @@
+// Package auth describes who a request is made by, for the layers past the
+// HTTP middleware.
+package auth
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// How the request was authenticated.
+const (
+	MethodBearer = "bearer"
+	MethodCookie = "cookie"
+	MethodAPIKey = "api_key"
+)
+
+// PrincipalKey is where JWTAuthMiddleware stores the principal in the gin
+// context.
+const PrincipalKey = "principal"
+
+type principalKey struct{}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID string
+	// Roles are those of the access token, API keys carry none
+	Roles []string
+	// Scopes are what an OAuth client was granted, or the API key's scopes
+	Scopes    []string
+	SessionID string
+	// TokenType is one of the service.TokenType values
+	TokenType  string
+	AuthMethod string
+	// ClientID names the OAuth client acting for the user
+	ClientID string
+	// ActorID is the admin impersonating the user
+	ActorID string
+	// AuthTime is when the user last proved who they are, zero when unknown
+	AuthTime time.Time
+	// TokenID is the jti of the access token, or the API key's ID
+	TokenID string
+	// FamilyID is the refresh token family the access token was issued from
+	FamilyID string
+	// Unverified marks users who haven't verified their email yet
+	Unverified bool
+	// ExpiresAt is when the access token expires, zero for API keys
+	ExpiresAt time.Time
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+func (p *Principal) Impersonated() bool {
+	return p.ActorID != ""
+}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request ctx belongs to. It takes
+// the request context as well as the gin context.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	if p, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return p, true
+	}
+
+	p, ok := ctx.Value(PrincipalKey).(*Principal)
+	return p, ok
+}
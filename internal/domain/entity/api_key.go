@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	// APIKeyScopeRead allows safe requests: GET, HEAD and OPTIONS.
+	APIKeyScopeRead = "read"
+	// APIKeyScopeWrite allows every other method.
+	APIKeyScopeWrite = "write"
+	// APIKeyScopeAdmin lets the key use the owner's admin permissions, on
+	// top of read or write.
+	APIKeyScopeAdmin = "admin"
+)
+
+// APIKey is a personal access token for scripts and CI. Only the SHA-256 of
+// the key is kept, Prefix is enough for the owner to recognise it.
+type APIKey struct {
+	ID         string     `json:"id" db:"id, primarykey"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// Allows reports whether the key's scopes cover a request with the given
+// HTTP method.
+func (k *APIKey) Allows(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return slices.Contains(k.Scopes, APIKeyScopeRead)
+	default:
+		return slices.Contains(k.Scopes, APIKeyScopeWrite)
+	}
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// EmailVerificationToken proves the user owns their email. Only the SHA-256
+// of the token is kept, the token itself goes by email.
+type EmailVerificationToken struct {
+	ID        string     `json:"id" db:"id, primarykey"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
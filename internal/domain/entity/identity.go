@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// Identity links a user to an account at an external OpenID Connect
+// provider, by the subject the provider gave it. A user can have several.
+type Identity struct {
+	ID       string `json:"id" db:"id, primarykey"`
+	UserID   string `json:"user_id" db:"user_id"`
+	Provider string `json:"provider" db:"provider"`
+	Subject  string `json:"subject" db:"subject"`
+	// Email is the one the provider reported when the identity was linked
+	Email       string     `json:"email" db:"email"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at" db:"last_login_at"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// ImpersonationAudit records a request made with an impersonation token.
+type ImpersonationAudit struct {
+	ID      string `json:"id" db:"id, primarykey"`
+	ActorID string `json:"actor_id" db:"actor_id"`
+	UserID  string `json:"user_id" db:"user_id"`
+	// TokenID groups the requests made with the same token
+	TokenID   string    `json:"token_id" db:"token_id"`
+	Method    string    `json:"method" db:"method"`
+	Path      string    `json:"path" db:"path"`
+	Status    int       `json:"status" db:"status"`
+	IP        string    `json:"ip" db:"ip"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
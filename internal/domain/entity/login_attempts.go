@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// LoginAttempts counts the recent failed logins of an account or an IP.
+type LoginAttempts struct {
+	Key           string     `json:"key" db:"key, primarykey"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until" db:"locked_until"`
+}
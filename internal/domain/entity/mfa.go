@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// TOTP is the authenticator app enrollment of a user. It only counts as
+// two-factor once confirmed with a first code.
+type TOTP struct {
+	UserID       string     `json:"user_id" db:"user_id, primarykey"`
+	Secret       string     `json:"-" db:"secret"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	ConfirmedAt  *time.Time `json:"confirmed_at" db:"confirmed_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (t *TOTP) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// OAuthClient is an application registered to use golerplate as its OAuth
+// server. Confidential clients authenticate with a secret, of which only the
+// SHA-256 is kept. Public clients, SPAs and mobile apps, can't keep one and
+// rely on PKCE alone.
+type OAuthClient struct {
+	ID         string `json:"id" db:"id, primarykey"`
+	Name       string `json:"name" db:"name"`
+	SecretHash string `json:"-" db:"secret_hash"`
+	Public     bool   `json:"public" db:"public"`
+	// RedirectURIs are matched exactly
+	RedirectURIs []string   `json:"redirect_uris" db:"redirect_uris"`
+	Scopes       []string   `json:"scopes" db:"scopes"`
+	RevokedAt    *time.Time `json:"-" db:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// AllowsScope reports whether every space separated scope was registered
+// for the client.
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// OAuthAuthorizationCode is the single-use code the authorization_code grant
+// exchanges for tokens, bound to the PKCE challenge of the request.
+type OAuthAuthorizationCode struct {
+	CodeHash      string `json:"-" db:"code_hash, primarykey"`
+	ClientID      string `json:"client_id" db:"client_id"`
+	UserID        string `json:"user_id" db:"user_id"`
+	RedirectURI   string `json:"redirect_uri" db:"redirect_uri"`
+	Scope         string `json:"scope" db:"scope"`
+	CodeChallenge string `json:"-" db:"code_challenge"`
+	// Nonce and AuthTime go into the ID token of OpenID Connect requests
+	Nonce     string     `json:"-" db:"nonce"`
+	AuthTime  *time.Time `json:"auth_time" db:"auth_time"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// RefreshToken is the persisted state of an issued refresh JWT. Every token
+// belongs to a family that starts at login and is carried over on each
+// rotation, so a replayed token can take down the whole chain.
+type RefreshToken struct {
+	ID        string     `json:"id" db:"id, primarykey"`
+	FamilyID  string     `json:"family_id" db:"family_id"`
+	SessionID string     `json:"session_id" db:"session_id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
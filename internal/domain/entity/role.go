@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+// RoleAdmin is seeded with every permission.
+const RoleAdmin = "admin"
+
+// Permissions are "<resource>:<action>" names, seeded by the migrations.
+const (
+	PermUsersRead  = "users:read"
+	PermUsersWrite = "users:write"
+	// PermUsersImpersonate lets support act as any user, every request is
+	// audited.
+	PermUsersImpersonate = "users:impersonate"
+	PermSessionsRead     = "sessions:read"
+	PermSessionsWrite    = "sessions:write"
+	PermRolesRead        = "roles:read"
+	PermRolesWrite       = "roles:write"
+	PermClientsRead      = "clients:read"
+	PermClientsWrite     = "clients:write"
+	// PermSecurityEventsRead lets auditors query the security events of
+	// every user.
+	PermSecurityEventsRead = "security_events:read"
+)
+
+type Role struct {
+	ID          string    `json:"id" db:"id, primarykey"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Permissions []string  `json:"permissions" db:"-"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
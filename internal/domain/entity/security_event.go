@@ -0,0 +1,71 @@
+package entity
+
+import "time"
+
+// Security event types.
+const (
+	EventRegister       = "register"
+	EventLogin          = "login"
+	EventLoginMFA       = "login_mfa"
+	EventLoginLink      = "login_link"
+	EventLoginPasskey   = "login_passkey"
+	EventLoginSSO       = "login_sso"
+	EventReauth         = "reauth"
+	EventRefresh        = "refresh"
+	EventTokenReuse     = "token_reuse"
+	EventLockout        = "lockout"
+	EventLogout         = "logout"
+	EventLogoutAll      = "logout_all"
+	EventPasswordChange = "password_change"
+	EventPasswordReset  = "password_reset"
+	EventAccountUnlock  = "account_unlock"
+	EventAccountDisable = "account_disable"
+	EventAccountEnable  = "account_enable"
+	EventImpersonation  = "impersonation"
+)
+
+// Security event outcomes.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// SecurityEvent is an append-only record of authentication activity.
+type SecurityEvent struct {
+	ID   string `json:"id" db:"id, primarykey"`
+	Type string `json:"type" db:"type"`
+	// Outcome is OutcomeSuccess or OutcomeFailure
+	Outcome string `json:"outcome" db:"outcome"`
+	// ActorID made the request, an admin acting on the user or the user
+	// itself. Empty when nobody could be identified.
+	ActorID string `json:"actor_id,omitempty" db:"actor_id"`
+	// UserID is the account the event is about, empty when unknown
+	UserID    string `json:"user_id,omitempty" db:"user_id"`
+	IP        string `json:"ip" db:"ip"`
+	UserAgent string `json:"user_agent" db:"user_agent"`
+	// Reason tells why the attempt failed
+	Reason    string    `json:"reason,omitempty" db:"reason"`
+	TraceID   string    `json:"trace_id,omitempty" db:"trace_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SecurityEventFilter narrows a query of security events, empty fields
+// match everything.
+type SecurityEventFilter struct {
+	UserID  string
+	ActorID string
+	Type    string
+	Outcome string
+	IP      string
+	Since   *time.Time
+	Until   *time.Time
+	// Before resumes a listing past the given event, ordered newest first
+	Before *SecurityEventCursor
+	Limit  int
+}
+
+// SecurityEventCursor is the position of an event in the newest first order.
+type SecurityEventCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
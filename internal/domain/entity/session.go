@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Session is a login on a given device. It lives as long as its refresh
+// token chain keeps being rotated.
+type Session struct {
+	ID         string     `json:"id" db:"id, primarykey"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IP         string     `json:"ip" db:"ip"`
+	DeviceName string     `json:"device_name" db:"device_name"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	// Current flags the session the request was made from
+	Current bool `json:"current" db:"-"`
+}
@@ -25,6 +25,13 @@ type User struct {
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
 	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+	// EmailVerifiedAt is set once the user follows the link sent at signup
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	// TokenVersion is carried by the user's tokens, refreshes are refused
+	// once it is bumped
+	TokenVersion int `json:"-" db:"token_version"`
+	// DisabledAt is set by an admin, disabled users can't log in
+	DisabledAt *time.Time `json:"-" db:"disabled_at"`
 }
 
 func NewUser(fullname, email, cpf, password string, age uint8) (*User, error) {
@@ -45,6 +52,33 @@ func NewUser(fullname, email, cpf, password string, age uint8) (*User, error) {
 	return u, nil
 }
 
+// NewFederatedUser is a user provisioned by a federated login, whose email
+// the identity provider verified. It has no password, CPF or age.
+func NewFederatedUser(fullname, email string) (*User, error) {
+	now := time.Now()
+	u := &User{
+		FullName:        fullname,
+		Email:           email,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := u.validateEmail(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
 func (u *User) Validate() error {
 	if err := u.validateEmail(); err != nil {
 		return err
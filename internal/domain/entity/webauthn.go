@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// WebAuthnCredential is a passkey registered by a user. SignCount is the
+// last counter the authenticator reported, a lower one hints at a clone.
+type WebAuthnCredential struct {
+	ID              string     `json:"id" db:"id, primarykey"`
+	UserID          string     `json:"user_id" db:"user_id"`
+	CredentialID    []byte     `json:"-" db:"credential_id"`
+	PublicKey       []byte     `json:"-" db:"public_key"`
+	AttestationType string     `json:"-" db:"attestation_type"`
+	AAGUID          []byte     `json:"-" db:"aaguid"`
+	SignCount       uint32     `json:"-" db:"sign_count"`
+	Transports      []string   `json:"transports" db:"transports"`
+	BackupEligible  bool       `json:"backup_eligible" db:"backup_eligible"`
+	BackupState     bool       `json:"backup_state" db:"backup_state"`
+	Name            string     `json:"name" db:"name"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt      *time.Time `json:"last_used_at" db:"last_used_at"`
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListByUser(ctx context.Context, userID string) ([]*entity.APIKey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Touch(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	// create issues a key and returns it with the record the repository got
+	create := func(t *testing.T, repo *MockAPIKeyRepository, apiKeyService service.APIKeyService) (*dto.APIKeyCreatedDTO, *entity.APIKey) {
+		var stored *entity.APIKey
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.APIKey")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.APIKey) }).
+			Return(nil).Once()
+
+		created, err := apiKeyService.Create(ctx, "user-id", dto.CreateAPIKeyDTO{Name: "CI", Scopes: []string{entity.APIKeyScopeRead}})
+		require.NoError(t, err)
+		return created, stored
+	}
+
+	t.Run("Stores only the hash and a prefix", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+		created, stored := create(t, repo, service.NewAPIKeyService(repo))
+
+		assert.True(t, strings.HasPrefix(created.Key, service.APIKeyPrefix))
+		assert.True(t, strings.HasPrefix(created.Key, stored.Prefix))
+		assert.Len(t, stored.KeyHash, 64)
+		assert.WithinDuration(t, time.Now().Add(90*24*time.Hour), stored.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Authenticates an active key and records its use", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(repo)
+		created, stored := create(t, repo, apiKeyService)
+
+		repo.On("GetByHash", mock.Anything, stored.KeyHash).Return(stored, nil).Once()
+		repo.On("Touch", mock.Anything, stored.ID).Return(nil).Once()
+
+		key, err := apiKeyService.Authenticate(ctx, created.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", key.UserID)
+		assert.True(t, key.Allows("GET"))
+		assert.False(t, key.Allows("POST"))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects revoked, expired and unknown keys", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(repo)
+		created, stored := create(t, repo, apiKeyService)
+
+		revokedAt := time.Now()
+		revoked := *stored
+		revoked.RevokedAt = &revokedAt
+		repo.On("GetByHash", mock.Anything, stored.KeyHash).Return(&revoked, nil).Once()
+
+		_, err := apiKeyService.Authenticate(ctx, created.Key)
+		assert.EqualError(t, err, constants.ErrMsgInvalidAPIKey)
+
+		expired := *stored
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+		repo.On("GetByHash", mock.Anything, stored.KeyHash).Return(&expired, nil).Once()
+
+		_, err = apiKeyService.Authenticate(ctx, created.Key)
+		assert.EqualError(t, err, constants.ErrMsgInvalidAPIKey)
+
+		repo.On("GetByHash", mock.Anything, mock.Anything).Return(nil, errors.New(constants.ErrMsgAPIKeyNotFound)).Once()
+
+		_, err = apiKeyService.Authenticate(ctx, service.APIKeyPrefix+"unknown")
+		assert.EqualError(t, err, constants.ErrMsgInvalidAPIKey)
+
+		repo.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revoking an ID that isn't a uuid finds nothing", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+
+		err := service.NewAPIKeyService(repo).Revoke(ctx, "user-id", "not-a-uuid")
+		assert.EqualError(t, err, constants.ErrMsgAPIKeyNotFound)
+		repo.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -1,53 +1,180 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/leonardonicola/golerplate/internal/domain/entity"
 	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
 	"github.com/leonardonicola/golerplate/pkg/constants"
 )
 
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	// TokenTypeMFAPending proves the password step of a login that still
+	// needs a second factor.
+	TokenTypeMFAPending = "mfa_pending"
+	// TokenTypeAPIKey marks the claims built for a request made with an API
+	// key, they are never signed.
+	TokenTypeAPIKey = "api_key"
+	// TokenTypeClient is issued to an OAuth client acting on its own behalf,
+	// with no user behind it.
+	TokenTypeClient = "client"
+)
+
+const mfaTokenTTL = 5 * time.Minute
+
+// impersonationTTL is short, an impersonation token can't be refreshed.
+const impersonationTTL = 15 * time.Minute
+
+// Actor is who really holds a token issued on behalf of the user, the act
+// claim of RFC 8693.
+type Actor struct {
+	UserID string `json:"sub"`
+}
+
 type Claims struct {
 	UserID string `json:"id"`
 	Type   string `json:"type"`
+	// FamilyID groups every refresh token rotated from the same login.
+	FamilyID  string `json:"fid,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	// Roles are only carried by access tokens, as they were when issued.
+	Roles []string `json:"roles,omitempty"`
+	// Unverified marks access tokens of users who haven't verified their
+	// email yet, when EmailVerificationRestrict is on.
+	Unverified bool `json:"unverified,omitempty"`
+	// ClientID names the OAuth client the token was issued to, empty for
+	// first-party logins.
+	ClientID string `json:"client_id,omitempty"`
+	// Scope is what the OAuth client was granted, space separated.
+	Scope string `json:"scope,omitempty"`
+	// AuthTime is when the user logged in, kept across refreshes.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// Act names the admin impersonating the user.
+	Act *Actor `json:"act,omitempty"`
+	// TokenVersion is the user's when issued, refreshes are refused once
+	// the user's is bumped. Only refresh tokens carry it, access tokens are
+	// cut off by the user's revocation in the revocation store.
+	TokenVersion int `json:"ver,omitempty"`
 	// Embedding
 	jwt.RegisteredClaims
 }
 
+// OAuthGrant is what a user granted an OAuth client.
+type OAuthGrant struct {
+	ClientID string
+	Scope    string
+}
+
 type AuthService interface {
-	GenerateToken(user *entity.User) (*dto.TokenResponseDTO, error)
-	RefreshToken(refreshToken string) (*dto.TokenResponseDTO, error)
+	GenerateToken(ctx context.Context, user *entity.User, meta SessionMeta) (*dto.TokenResponseDTO, error)
+	// GrantToken is GenerateToken for an OAuth client acting for the user.
+	// The tokens carry the grant and no roles, admin routes stay out of
+	// reach of third parties.
+	GrantToken(ctx context.Context, user *entity.User, meta SessionMeta, grant OAuthGrant) (*dto.TokenResponseDTO, error)
+	// RefreshToken rotates a refresh token issued to clientID, empty for
+	// first-party logins.
+	RefreshToken(ctx context.Context, refreshToken, clientID string) (*dto.TokenResponseDTO, error)
+	// ClientToken issues an access token to the client itself, for the
+	// client_credentials grant. It has no refresh token.
+	ClientToken(client *entity.OAuthClient, scope string) (string, error)
+	Logout(ctx context.Context, claims *Claims) error
+	// LogoutAll bumps the user's token version and revokes every token
+	// issued to the user so far.
+	LogoutAll(ctx context.Context, userID string) error
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+	MFAToken(user *entity.User) (string, error)
+	// ImpersonationToken issues actorID an access token acting as the user.
+	// It has no session, no refresh token and no roles, so admin routes
+	// can't be reached through it.
+	ImpersonationToken(user *entity.User, actorID string) (string, time.Time, error)
+	ParseMFAToken(ctx context.Context, mfaToken string) (*Claims, error)
+	// ReauthToken issues a new access token for the session of claims, with
+	// auth_time set to now. It is meant for once the user proved who they
+	// are again, claims must have passed IsRevoked.
+	ReauthToken(ctx context.Context, claims *Claims) (string, time.Time, error)
 }
 
 type authService struct {
-	accessSecret  string
-	refreshSecret string
+	accessSigner  Signer
+	refreshSigner Signer
 	accessTTL     time.Duration
 	refreshTTL    time.Duration
+	refreshRepo   repository.RefreshTokenRepository
+	revocations   repository.RevocationStore
+	sessions      SessionService
+	rbac          RBACService
+	users         UserService
+	verification  EmailVerificationMode
+	log           *log.Logger
 }
 
-func NewAuthService(accessSecret, refreshSecret string, accessTTL, refreshTTL time.Duration) *authService {
+func NewAuthService(accessSigner, refreshSigner Signer, accessTTL, refreshTTL time.Duration, refreshRepo repository.RefreshTokenRepository, revocations repository.RevocationStore, sessions SessionService, rbac RBACService, users UserService, verification EmailVerificationMode) *authService {
 	return &authService{
-		accessSecret:  accessSecret,
+		accessSigner:  accessSigner,
 		accessTTL:     accessTTL,
-		refreshSecret: refreshSecret,
+		refreshSigner: refreshSigner,
 		refreshTTL:    refreshTTL,
+		refreshRepo:   refreshRepo,
+		revocations:   revocations,
+		sessions:      sessions,
+		rbac:          rbac,
+		users:         users,
+		verification:  verification,
+		log:           log.Default(),
 	}
 }
 
-func (s *authService) GenerateToken(user *entity.User) (*dto.TokenResponseDTO, error) {
+func (s *authService) GenerateToken(ctx context.Context, user *entity.User, meta SessionMeta) (*dto.TokenResponseDTO, error) {
+	return s.GrantToken(ctx, user, meta, OAuthGrant{})
+}
+
+func (s *authService) GrantToken(ctx context.Context, user *entity.User, meta SessionMeta, grant OAuthGrant) (*dto.TokenResponseDTO, error) {
+	if user.Disabled() {
+		return nil, errors.New(constants.ErrMsgAccountDisabled)
+	}
+
+	if s.verification == EmailVerificationBlock && !user.EmailVerified() {
+		return nil, errors.New(constants.ErrMsgEmailNotVerified)
+	}
+
+	session, err := s.sessions.Start(ctx, user.ID, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+
+	// Every login starts a new refresh token family
+	return s.tokenPair(ctx, user, uuid.NewString(), session.ID, jwt.NewNumericDate(time.Now()), grant)
+}
+
+func (s *authService) tokenPair(ctx context.Context, user *entity.User, familyID, sessionID string, authTime *jwt.NumericDate, grant OAuthGrant) (*dto.TokenResponseDTO, error) {
+	var roles []string
+	if grant.ClientID == "" {
+		// Roles are looked up on every pair, a refresh picks up role changes
+		var err error
+		roles, err = s.rbac.UserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load roles: %w", err)
+		}
+	}
+
 	// Generate access token
-	accessToken, err := s.accessToken(user)
+	unverified := s.verification == EmailVerificationRestrict && !user.EmailVerified()
+	accessToken, err := s.accessToken(user, familyID, sessionID, roles, unverified, authTime, grant)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create access token: %w", err)
 	}
 
 	// Generate refresh token
-	refreshToken, err := s.refreshToken(user)
+	refreshToken, err := s.refreshToken(ctx, user, familyID, sessionID, authTime, grant)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create refresh token: %w", err)
 	}
@@ -58,84 +185,290 @@ func (s *authService) GenerateToken(user *entity.User) (*dto.TokenResponseDTO, e
 	}, nil
 }
 
-func (s *authService) accessToken(user *entity.User) (string, error) {
+func (s *authService) accessToken(user *entity.User, familyID, sessionID string, roles []string, unverified bool, authTime *jwt.NumericDate, grant OAuthGrant) (string, error) {
 	now := time.Now()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		UserID: user.ID,
-		Type:   "access",
+	return s.accessSigner.Sign(Claims{
+		UserID:     user.ID,
+		Type:       TokenTypeAccess,
+		FamilyID:   familyID,
+		SessionID:  sessionID,
+		Roles:      roles,
+		Unverified: unverified,
+		ClientID:   grant.ClientID,
+		Scope:      grant.Scope,
+		AuthTime:   authTime,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	})
-
-	return token.SignedString([]byte(s.accessSecret))
 }
 
-func (s *authService) refreshToken(user *entity.User) (string, error) {
+func (s *authService) refreshToken(ctx context.Context, user *entity.User, familyID, sessionID string, authTime *jwt.NumericDate, grant OAuthGrant) (string, error) {
 	now := time.Now()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		UserID: user.ID,
-		Type:   "refresh",
+	record := &entity.RefreshToken{
+		ID:        uuid.NewString(),
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+
+	signed, err := s.refreshSigner.Sign(Claims{
+		UserID:       user.ID,
+		Type:         TokenTypeRefresh,
+		FamilyID:     familyID,
+		SessionID:    sessionID,
+		ClientID:     grant.ClientID,
+		Scope:        grant.Scope,
+		AuthTime:     authTime,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.refreshTTL)),
+			ID:        record.ID,
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.refreshRepo.Create(ctx, record); err != nil {
+		return "", err
+	}
 
-	return token.SignedString([]byte(s.refreshSecret))
+	return signed, nil
 }
 
-func (s *authService) RefreshToken(refreshToken string) (*dto.TokenResponseDTO, error) {
+func (s *authService) RefreshToken(ctx context.Context, refreshToken, clientID string) (*dto.TokenResponseDTO, error) {
 	// Parse and validate refresh token
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New(constants.ErrMsgInvalidToken)
-		}
-		return []byte(s.refreshSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(refreshToken, &Claims{}, s.refreshSigner.Keyfunc)
 
 	if err != nil {
 		return nil, errors.New(constants.ErrMsgInvalidToken)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
 		return nil, errors.New(constants.ErrMsgInvalidToken)
 	}
 
-	// Extract user information
-	userID, ok := claims["id"].(string)
+	if claims.Type != TokenTypeRefresh || claims.UserID == "" || claims.ID == "" {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	// Checked before it is used, a stolen token can't be burnt by another
+	if claims.ClientID != clientID {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
 
-	if !ok {
+	// Each refresh token can only be exchanged once
+	used, err := s.refreshRepo.MarkUsed(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !used {
+		return nil, s.rejectRefresh(ctx, claims)
+	}
+
+	// The user's current state decides, a deleted or disabled account, or
+	// a password changed since, refuses the token
+	user, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil {
 		return nil, errors.New(constants.ErrMsgInvalidToken)
 	}
 
-	if claims["type"] != "refresh" {
+	if user.Disabled() {
+		return nil, errors.New(constants.ErrMsgAccountDisabled)
+	}
+
+	if user.TokenVersion != claims.TokenVersion {
 		return nil, errors.New(constants.ErrMsgInvalidToken)
 	}
 
-	user := &entity.User{
-		ID: userID,
+	if s.verification == EmailVerificationBlock && !user.EmailVerified() {
+		return nil, errors.New(constants.ErrMsgEmailNotVerified)
 	}
 
-	// Generate access token
-	accessToken, err := s.accessToken(user)
+	if claims.SessionID != "" {
+		if err := s.sessions.Touch(ctx, claims.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate new token pair
+	return s.tokenPair(ctx, user, claims.FamilyID, claims.SessionID, claims.AuthTime, OAuthGrant{ClientID: claims.ClientID, Scope: claims.Scope})
+}
+
+func (s *authService) ClientToken(client *entity.OAuthClient, scope string) (string, error) {
+	now := time.Now()
+	return s.accessSigner.Sign(Claims{
+		Type:     TokenTypeClient,
+		ClientID: client.ID,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   client.ID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	})
+}
+
+func (s *authService) ImpersonationToken(user *entity.User, actorID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(impersonationTTL)
+
+	token, err := s.accessSigner.Sign(Claims{
+		UserID:     user.ID,
+		Type:       TokenTypeAccess,
+		Unverified: s.verification != EmailVerificationAllow && !user.EmailVerified(),
+		Act:        &Actor{UserID: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create access token: %w", err)
+		return "", time.Time{}, err
 	}
 
-	// Generate refresh token
-	refreshToken, err = s.refreshToken(user)
+	return token, expiresAt, nil
+}
+
+func (s *authService) ReauthToken(ctx context.Context, claims *Claims) (string, time.Time, error) {
+	user, err := s.users.GetByID(ctx, claims.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+		return "", time.Time{}, errors.New(constants.ErrMsgInvalidToken)
 	}
 
-	// Generate new token pair
-	return &dto.TokenResponseDTO{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	if user.Disabled() {
+		return "", time.Time{}, errors.New(constants.ErrMsgAccountDisabled)
+	}
+
+	roles, err := s.rbac.UserRoles(ctx, user.ID)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	now := time.Now()
+	unverified := s.verification == EmailVerificationRestrict && !user.EmailVerified()
+	token, err := s.accessToken(user, claims.FamilyID, claims.SessionID, roles, unverified, jwt.NewNumericDate(now), OAuthGrant{})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return token, now.Add(s.accessTTL), nil
+}
+
+// rejectRefresh works out why a refresh token could not be consumed. A token
+// that was already rotated means it leaked, so the whole family goes down.
+func (s *authService) rejectRefresh(ctx context.Context, claims *Claims) error {
+	record, err := s.refreshRepo.GetByID(ctx, claims.ID)
+	if err != nil {
+		return errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	if record.UsedAt == nil || record.UserID != claims.UserID {
+		return errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	if err := s.refreshRepo.RevokeFamily(ctx, record.FamilyID); err != nil {
+		return err
+	}
+
+	s.log.Printf("SECURITY: refresh token reuse detected, family revoked (user=%s family=%s jti=%s)",
+		record.UserID, record.FamilyID, record.ID)
+
+	return errors.New(constants.ErrMsgTokenReused)
+}
+
+// Logout revokes the given access token and ends the session it was issued
+// for.
+func (s *authService) Logout(ctx context.Context, claims *Claims) error {
+	expiresAt := time.Now().Add(s.accessTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	if err := s.revocations.RevokeToken(ctx, claims.ID, expiresAt); err != nil {
+		return err
+	}
+
+	if claims.SessionID != "" {
+		return s.sessions.Revoke(ctx, claims.UserID, claims.SessionID)
+	}
+
+	if claims.FamilyID == "" {
+		return nil
+	}
+
+	return s.refreshRepo.RevokeFamily(ctx, claims.FamilyID)
+}
+
+func (s *authService) LogoutAll(ctx context.Context, userID string) error {
+	// Refresh tokens outside of any session are refused too
+	if err := s.users.BumpTokenVersion(ctx, userID); err != nil && err.Error() != constants.ErrMsgUserNotFound {
+		return err
+	}
+
+	return s.sessions.RevokeAll(ctx, userID)
+}
+
+func (s *authService) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	return s.revocations.IsRevoked(ctx, claims.ID, claims.SessionID, claims.UserID, issuedAt)
+}
+
+// MFAToken issues the short lived token a login gets in place of the token
+// pair when the user has two-factor enabled. It is signed with the refresh
+// key, which only this service verifies, so it never opens protected routes.
+func (s *authService) MFAToken(user *entity.User) (string, error) {
+	now := time.Now()
+	return s.refreshSigner.Sign(Claims{
+		UserID: user.ID,
+		Type:   TokenTypeMFAPending,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	})
+}
+
+// ParseMFAToken validates a pending MFA token. Revoke it with Logout once
+// the second factor is verified so it can't be exchanged twice.
+func (s *authService) ParseMFAToken(ctx context.Context, mfaToken string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(mfaToken, &Claims{}, s.refreshSigner.Keyfunc)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.Type != TokenTypeMFAPending || claims.ID == "" {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	revoked, err := s.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	return claims, nil
 }
@@ -0,0 +1,388 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByID(ctx context.Context, id string) (*entity.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeBySession(ctx context.Context, sessionID string) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+func newAuthService(accessSecret, refreshSecret string, repo *MockRefreshTokenRepository, sessionRepo *MockSessionRepository) service.AuthService {
+	revocations := repository.NewMemoryRevocationStore()
+	sessionService := service.NewSessionService(sessionRepo, repo, revocations, time.Minute, time.Hour)
+
+	rbacRepo := new(MockRBACRepository)
+	rbacRepo.On("UserRoles", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+
+	// Refreshes load the user, who is active and on the first token version
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", mock.Anything, mock.Anything).Return(&entity.User{ID: "user-id"}, nil).Maybe()
+	userRepo.On("BumpTokenVersion", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return service.NewAuthService(
+		service.NewHMACSigner("", accessSecret),
+		service.NewHMACSigner("", refreshSecret),
+		time.Minute,
+		time.Hour,
+		repo,
+		revocations,
+		sessionService,
+		service.NewRBACService(rbacRepo),
+		newUserService(userRepo),
+		service.EmailVerificationAllow,
+	)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	user := &entity.User{ID: "user-id"}
+
+	t.Run("Rotates within the same family", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		sessionRepo := new(MockSessionRepository)
+		authService := newAuthService("access", "refresh", repo, sessionRepo)
+
+		var issued []*entity.RefreshToken
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).
+			Run(func(args mock.Arguments) {
+				issued = append(issued, args.Get(1).(*entity.RefreshToken))
+			}).Return(nil)
+
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil)
+
+		pair, err := authService.GenerateToken(ctx, user, service.SessionMeta{UserAgent: "test"})
+		assert.NoError(t, err)
+
+		repo.On("MarkUsed", mock.Anything, mock.AnythingOfType("string")).Return(true, nil).Once()
+		sessionRepo.On("Touch", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		rotated, err := authService.RefreshToken(ctx, pair.RefreshToken, "")
+		assert.NoError(t, err)
+		assert.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+
+		// The login time survives the rotation
+		first, second := &service.Claims{}, &service.Claims{}
+		_, err = jwt.ParseWithClaims(pair.AccessToken, first, service.NewHMACSigner("", "access").Keyfunc)
+		assert.NoError(t, err)
+		_, err = jwt.ParseWithClaims(rotated.AccessToken, second, service.NewHMACSigner("", "access").Keyfunc)
+		assert.NoError(t, err)
+		assert.NotNil(t, first.AuthTime)
+		assert.Equal(t, first.AuthTime, second.AuthTime)
+
+		assert.Len(t, issued, 2)
+		assert.Equal(t, issued[0].FamilyID, issued[1].FamilyID)
+		assert.NotEqual(t, issued[0].ID, issued[1].ID)
+		assert.NotEmpty(t, issued[0].SessionID)
+		assert.Equal(t, issued[0].SessionID, issued[1].SessionID)
+		repo.AssertCalled(t, "MarkUsed", mock.Anything, issued[0].ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Reuse revokes the family", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		sessionRepo := new(MockSessionRepository)
+		authService := newAuthService("access", "refresh", repo, sessionRepo)
+
+		var record *entity.RefreshToken
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).
+			Run(func(args mock.Arguments) {
+				record = args.Get(1).(*entity.RefreshToken)
+			}).Return(nil).Once()
+
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil)
+
+		pair, err := authService.GenerateToken(ctx, user, service.SessionMeta{UserAgent: "test"})
+		assert.NoError(t, err)
+
+		usedAt := time.Now()
+		record.UsedAt = &usedAt
+
+		repo.On("MarkUsed", mock.Anything, record.ID).Return(false, nil)
+		repo.On("GetByID", mock.Anything, record.ID).Return(record, nil)
+		repo.On("RevokeFamily", mock.Anything, record.FamilyID).Return(nil)
+
+		got, err := authService.RefreshToken(ctx, pair.RefreshToken, "")
+		assert.Nil(t, got)
+		assert.EqualError(t, err, constants.ErrMsgTokenReused)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects access tokens", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		sessionRepo := new(MockSessionRepository)
+		authService := newAuthService("secret", "secret", repo, sessionRepo)
+
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).Return(nil)
+
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil)
+
+		pair, err := authService.GenerateToken(ctx, user, service.SessionMeta{UserAgent: "test"})
+		assert.NoError(t, err)
+
+		got, err := authService.RefreshToken(ctx, pair.AccessToken, "")
+		assert.Nil(t, got)
+		assert.EqualError(t, err, constants.ErrMsgInvalidToken)
+		repo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRefreshRevalidatesUser(t *testing.T) {
+	ctx := context.Background()
+	disabledAt := time.Now()
+
+	// refresh logs user in, then refreshes once the user is stored as current
+	refresh := func(t *testing.T, user, current *entity.User, currentErr error) (*dto.TokenResponseDTO, error) {
+		repo := new(MockRefreshTokenRepository)
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).Return(nil)
+		repo.On("MarkUsed", mock.Anything, mock.AnythingOfType("string")).Return(true, nil)
+
+		sessionRepo := new(MockSessionRepository)
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil)
+		sessionRepo.On("Touch", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil).Maybe()
+
+		rbacRepo := new(MockRBACRepository)
+		rbacRepo.On("UserRoles", mock.Anything, mock.Anything).Return([]string{}, nil)
+
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(current, currentErr)
+
+		revocations := repository.NewMemoryRevocationStore()
+		authService := service.NewAuthService(
+			service.NewHMACSigner("", "access"),
+			service.NewHMACSigner("", "refresh"),
+			time.Minute,
+			time.Hour,
+			repo,
+			revocations,
+			service.NewSessionService(sessionRepo, repo, revocations, time.Minute, time.Hour),
+			service.NewRBACService(rbacRepo),
+			newUserService(userRepo),
+			service.EmailVerificationAllow,
+		)
+
+		pair, err := authService.GenerateToken(ctx, user, service.SessionMeta{UserAgent: "test"})
+		require.NoError(t, err)
+
+		return authService.RefreshToken(ctx, pair.RefreshToken, "")
+	}
+
+	user := &entity.User{ID: "user-id", TokenVersion: 3}
+
+	t.Run("Refreshes while the version matches", func(t *testing.T) {
+		pair, err := refresh(t, user, &entity.User{ID: "user-id", TokenVersion: 3}, nil)
+		require.NoError(t, err)
+
+		claims := &service.Claims{}
+		_, err = jwt.ParseWithClaims(pair.RefreshToken, claims, service.NewHMACSigner("", "refresh").Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, 3, claims.TokenVersion)
+
+		// Access tokens are cut off in the revocation store instead
+		access := &service.Claims{}
+		_, err = jwt.ParseWithClaims(pair.AccessToken, access, service.NewHMACSigner("", "access").Keyfunc)
+		require.NoError(t, err)
+		assert.Zero(t, access.TokenVersion)
+	})
+
+	t.Run("Refuses a bumped version", func(t *testing.T) {
+		_, err := refresh(t, user, &entity.User{ID: "user-id", TokenVersion: 4}, nil)
+		assert.EqualError(t, err, constants.ErrMsgInvalidToken)
+	})
+
+	t.Run("Refuses disabled users", func(t *testing.T) {
+		_, err := refresh(t, user, &entity.User{ID: "user-id", TokenVersion: 3, DisabledAt: &disabledAt}, nil)
+		assert.EqualError(t, err, constants.ErrMsgAccountDisabled)
+	})
+
+	t.Run("Refuses deleted users", func(t *testing.T) {
+		_, err := refresh(t, user, nil, errors.New(constants.ErrMsgUserNotFound))
+		assert.EqualError(t, err, constants.ErrMsgInvalidToken)
+	})
+
+	t.Run("Disabled users can't log in", func(t *testing.T) {
+		authService := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository))
+
+		_, err := authService.GenerateToken(ctx, &entity.User{ID: "user-id", DisabledAt: &disabledAt}, service.SessionMeta{})
+		assert.EqualError(t, err, constants.ErrMsgAccountDisabled)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Revokes the token and its family", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		sessionRepo := new(MockSessionRepository)
+		authService := newAuthService("access", "refresh", repo, sessionRepo)
+
+		claims := &service.Claims{
+			UserID:   "user-id",
+			FamilyID: "family-id",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "jti",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		other := &service.Claims{
+			UserID:           "user-id",
+			RegisteredClaims: jwt.RegisteredClaims{ID: "other-jti", IssuedAt: jwt.NewNumericDate(now)},
+		}
+
+		repo.On("RevokeFamily", mock.Anything, "family-id").Return(nil)
+
+		assert.NoError(t, authService.Logout(ctx, claims))
+
+		revoked, err := authService.IsRevoked(ctx, claims)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = authService.IsRevoked(ctx, other)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Logout all revokes tokens issued before", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		sessionRepo := new(MockSessionRepository)
+		authService := newAuthService("access", "refresh", repo, sessionRepo)
+
+		claims := &service.Claims{
+			UserID:           "user-id",
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti", IssuedAt: jwt.NewNumericDate(now.Add(-time.Minute))},
+		}
+
+		repo.On("RevokeByUser", mock.Anything, "user-id").Return(nil)
+		sessionRepo.On("RevokeByUser", mock.Anything, "user-id").Return(nil)
+
+		assert.NoError(t, authService.LogoutAll(ctx, "user-id"))
+
+		revoked, err := authService.IsRevoked(ctx, claims)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		// iat is in whole seconds, a login right after must not be caught
+		claims.IssuedAt = jwt.NewNumericDate(time.Now())
+		revoked, err = authService.IsRevoked(ctx, claims)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+
+		claims.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute))
+		revoked, err = authService.IsRevoked(ctx, claims)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+		repo.AssertExpectations(t)
+		sessionRepo.AssertExpectations(t)
+	})
+}
+
+func TestReauthToken(t *testing.T) {
+	ctx := context.Background()
+	authService := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository))
+	loggedInAt := jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	token, expiresAt, err := authService.ReauthToken(ctx, &service.Claims{
+		UserID:    "user-id",
+		SessionID: "session-id",
+		FamilyID:  "family-id",
+		AuthTime:  loggedInAt,
+	})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	claims := &service.Claims{}
+	_, err = jwt.ParseWithClaims(token, claims, service.NewHMACSigner("", "access").Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, service.TokenTypeAccess, claims.Type)
+	assert.Equal(t, "session-id", claims.SessionID)
+	assert.Equal(t, "family-id", claims.FamilyID)
+	require.NotNil(t, claims.AuthTime)
+	assert.WithinDuration(t, time.Now(), claims.AuthTime.Time, time.Second)
+
+	// withUser is an auth service whose user store only knows user
+	withUser := func(user *entity.User) service.AuthService {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		rbacRepo := new(MockRBACRepository)
+		rbacRepo.On("UserRoles", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+		revocations := repository.NewMemoryRevocationStore()
+
+		return service.NewAuthService(
+			service.NewHMACSigner("", "access"),
+			service.NewHMACSigner("", "refresh"),
+			time.Minute,
+			time.Hour,
+			new(MockRefreshTokenRepository),
+			revocations,
+			service.NewSessionService(new(MockSessionRepository), new(MockRefreshTokenRepository), revocations, time.Minute, time.Hour),
+			service.NewRBACService(rbacRepo),
+			newUserService(userRepo),
+			service.EmailVerificationAllow,
+		)
+	}
+
+	t.Run("Works once the user logged out everywhere", func(t *testing.T) {
+		// Access tokens carry no version, a bumped one must not matter
+		authService := withUser(&entity.User{ID: "user-id", TokenVersion: 2})
+
+		_, _, err := authService.ReauthToken(ctx, &service.Claims{UserID: "user-id", SessionID: "session-id", AuthTime: loggedInAt})
+		require.NoError(t, err)
+	})
+
+	t.Run("Disabled users can't re-authenticate", func(t *testing.T) {
+		disabledAt := time.Now()
+		authService := withUser(&entity.User{ID: "user-id", DisabledAt: &disabledAt})
+
+		_, _, err := authService.ReauthToken(ctx, &service.Claims{UserID: "user-id"})
+		assert.EqualError(t, err, constants.ErrMsgAccountDisabled)
+	})
+}
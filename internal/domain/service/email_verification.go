@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// EmailVerificationMode decides what users who haven't verified their email
+// can do.
+type EmailVerificationMode string
+
+const (
+	// EmailVerificationAllow lets them in like anyone else.
+	EmailVerificationAllow EmailVerificationMode = "allow"
+	// EmailVerificationRestrict gives them access tokens marked unverified,
+	// kept away from the routes that need a verified email.
+	EmailVerificationRestrict EmailVerificationMode = "restrict"
+	// EmailVerificationBlock refuses to sign them in.
+	EmailVerificationBlock EmailVerificationMode = "block"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	// A link is sent at most once a minute and five times an hour
+	emailVerificationCooldown    = time.Minute
+	emailVerificationHourlyLimit = 5
+)
+
+type EmailVerificationService interface {
+	// Send emails a verification link to the user.
+	Send(ctx context.Context, user *entity.User) error
+	// Resend emails a new link when the email belongs to an unverified user
+	// and the limits allow it, and does nothing otherwise. Callers must not
+	// tell the cases apart.
+	Resend(ctx context.Context, email string) error
+	// Verify marks the email of the token's user as verified.
+	Verify(ctx context.Context, token string) error
+}
+
+type emailVerificationService struct {
+	repo        repository.EmailVerificationRepository
+	userService UserService
+	mailer      mail.Sender
+	verifyURL   string
+	log         *log.Logger
+}
+
+// NewEmailVerificationService sends links to verifyURL with the token in the
+// "token" query parameter.
+func NewEmailVerificationService(r repository.EmailVerificationRepository, us UserService, mailer mail.Sender, verifyURL string) *emailVerificationService {
+	return &emailVerificationService{
+		repo:        r,
+		userService: us,
+		mailer:      mailer,
+		verifyURL:   verifyURL,
+		log:         log.Default(),
+	}
+}
+
+func (s *emailVerificationService) Send(ctx context.Context, user *entity.User) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Create(ctx, &entity.EmailVerificationToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	mail.SendInBackground(ctx, s.mailer, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Welcome! Confirm this is your email by following this link within %d hours:\n\n%s?token=%s",
+			int(emailVerificationTTL.Hours()), s.verifyURL, url.QueryEscape(token)),
+	})
+
+	return nil
+}
+
+func (s *emailVerificationService) Resend(ctx context.Context, email string) error {
+	user, err := s.userService.GetByEmail(ctx, email)
+	if err != nil {
+		if err.Error() == constants.ErrMsgUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+
+	recent, err := s.repo.CountSince(ctx, user.ID, now.Add(-emailVerificationCooldown))
+	if err != nil {
+		return err
+	}
+
+	hourly, err := s.repo.CountSince(ctx, user.ID, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if recent > 0 || hourly >= emailVerificationHourlyLimit {
+		s.log.Printf("EMAIL VERIFICATION: resend to user %s skipped, too many recent links", user.ID)
+		return nil
+	}
+
+	return s.Send(ctx, user)
+}
+
+func (s *emailVerificationService) Verify(ctx context.Context, token string) error {
+	userID, err := s.repo.Consume(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	return s.userService.MarkEmailVerified(ctx, userID)
+}
@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) Create(ctx context.Context, token *entity.EmailVerificationToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) Consume(ctx context.Context, tokenHash string) (string, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func TestEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	user := &entity.User{ID: "user-id", Email: "test@example.com"}
+
+	t.Run("Verifies the email with the emailed link", func(t *testing.T) {
+		var stored *entity.EmailVerificationToken
+		repo := new(MockEmailVerificationRepository)
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.EmailVerificationToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.EmailVerificationToken) }).
+			Return(nil)
+		userRepo := new(MockUserRepository)
+		userRepo.On("MarkEmailVerified", mock.Anything, user.ID).Return(nil).Once()
+
+		sent := make(outbox, 1)
+		verifications := service.NewEmailVerificationService(repo, newUserService(userRepo), sent, "http://app/verify-email")
+
+		require.NoError(t, verifications.Send(ctx, user))
+
+		var msg mail.Message
+		select {
+		case msg = <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("no email sent")
+		}
+
+		link := regexp.MustCompile(`http://app/verify-email\?token=(\S+)`).FindStringSubmatch(msg.Body)
+		require.Len(t, link, 2)
+		token, err := url.QueryUnescape(link[1])
+		require.NoError(t, err)
+
+		repo.On("Consume", mock.Anything, stored.TokenHash).Return(user.ID, nil).Once()
+
+		require.NoError(t, verifications.Verify(ctx, token))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Resend stays silent while a link was just sent", func(t *testing.T) {
+		repo := new(MockEmailVerificationRepository)
+		repo.On("CountSince", mock.Anything, user.ID, mock.AnythingOfType("time.Time")).Return(1, nil)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		sent := make(outbox, 1)
+		verifications := service.NewEmailVerificationService(repo, newUserService(userRepo), sent, "http://app/verify-email")
+
+		require.NoError(t, verifications.Resend(ctx, user.Email))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Resend stays silent for unknown and verified emails", func(t *testing.T) {
+		verifiedAt := time.Now()
+		repo := new(MockEmailVerificationRepository)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, "ghost@example.com").Return(nil, errors.New(constants.ErrMsgUserNotFound))
+		userRepo.On("GetByEmail", mock.Anything, "done@example.com").Return(&entity.User{ID: "done", EmailVerifiedAt: &verifiedAt}, nil)
+
+		verifications := service.NewEmailVerificationService(repo, newUserService(userRepo), make(outbox, 1), "http://app/verify-email")
+
+		require.NoError(t, verifications.Resend(ctx, "ghost@example.com"))
+		require.NoError(t, verifications.Resend(ctx, "done@example.com"))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEmailVerificationModes(t *testing.T) {
+	ctx := context.Background()
+	unverified := &entity.User{ID: "user-id"}
+
+	newModeAuthService := func(mode service.EmailVerificationMode) (service.AuthService, service.Signer) {
+		refreshRepo := new(MockRefreshTokenRepository)
+		refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).Return(nil).Maybe()
+		sessionRepo := new(MockSessionRepository)
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil).Maybe()
+		rbacRepo := new(MockRBACRepository)
+		rbacRepo.On("UserRoles", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+
+		revocations := repository.NewMemoryRevocationStore()
+		accessSigner := service.NewHMACSigner("", "access")
+		return service.NewAuthService(
+			accessSigner,
+			service.NewHMACSigner("", "refresh"),
+			time.Minute,
+			time.Hour,
+			refreshRepo,
+			revocations,
+			service.NewSessionService(sessionRepo, refreshRepo, revocations, time.Minute, time.Hour),
+			service.NewRBACService(rbacRepo),
+			newUserService(new(MockUserRepository)),
+			mode,
+		), accessSigner
+	}
+
+	t.Run("Restrict marks the access token", func(t *testing.T) {
+		authService, accessSigner := newModeAuthService(service.EmailVerificationRestrict)
+
+		pair, err := authService.GenerateToken(ctx, unverified, service.SessionMeta{})
+		require.NoError(t, err)
+
+		claims := &service.Claims{}
+		_, err = jwt.ParseWithClaims(pair.AccessToken, claims, accessSigner.Keyfunc)
+		require.NoError(t, err)
+		assert.True(t, claims.Unverified)
+	})
+
+	t.Run("Block refuses to sign in", func(t *testing.T) {
+		authService, _ := newModeAuthService(service.EmailVerificationBlock)
+
+		_, err := authService.GenerateToken(ctx, unverified, service.SessionMeta{})
+		assert.EqualError(t, err, constants.ErrMsgEmailNotVerified)
+	})
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/internal/infra/sso"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// TokenTypeFederatedLogin carries the state, nonce and PKCE verifier of a
+// login at an identity provider until the provider redirects back.
+const TokenTypeFederatedLogin = "federated_login"
+
+const federatedLoginTTL = 10 * time.Minute
+
+type FederationService interface {
+	Providers() []string
+	BeginLogin(ctx context.Context, provider string) (*dto.FederatedLoginDTO, error)
+	// FinishLogin returns the user of the identity. An unknown identity is
+	// linked to the user with the same email, or to a new user, when the
+	// provider verified the email.
+	FinishLogin(ctx context.Context, flow, code, state string) (*entity.User, error)
+	// BeginLink starts a login at the provider whose identity is linked to
+	// the logged in user.
+	BeginLink(ctx context.Context, provider, userID string) (*dto.FederatedLoginDTO, error)
+	FinishLink(ctx context.Context, userID, flow, code, state string) (*entity.Identity, error)
+	List(ctx context.Context, userID string) ([]*entity.Identity, error)
+	Unlink(ctx context.Context, userID, id string) error
+}
+
+type federatedLoginClaims struct {
+	Type     string `json:"type"`
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// LinkUserID is set when a logged in user links the identity
+	LinkUserID string `json:"link_user_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type federationService struct {
+	providers   map[string]sso.Provider
+	repo        repository.IdentityRepository
+	userService UserService
+	signer      Signer
+	revocations repository.RevocationStore
+	log         *log.Logger
+}
+
+// NewFederationService keeps no login state server side, like passkey
+// ceremonies it travels in a token signed with signer, burned once used.
+func NewFederationService(providers []sso.Provider, r repository.IdentityRepository, us UserService, signer Signer, revocations repository.RevocationStore) *federationService {
+	byName := make(map[string]sso.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &federationService{
+		providers:   byName,
+		repo:        r,
+		userService: us,
+		signer:      signer,
+		revocations: revocations,
+		log:         log.Default(),
+	}
+}
+
+func (s *federationService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *federationService) BeginLogin(ctx context.Context, provider string) (*dto.FederatedLoginDTO, error) {
+	return s.begin(ctx, provider, "")
+}
+
+func (s *federationService) FinishLogin(ctx context.Context, flow, code, state string) (*entity.User, error) {
+	login, claims, err := s.finish(ctx, flow, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if login.LinkUserID != "" {
+		return nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	identity, err := s.repo.GetBySubject(ctx, login.Provider, claims.Subject)
+	if err == nil {
+		if err := s.repo.Touch(ctx, identity.ID); err != nil {
+			s.log.Printf("FEDERATION SERVICE: failed to touch identity %s: %s", identity.ID, err.Error())
+		}
+		return s.userService.GetByID(ctx, identity.UserID)
+	}
+
+	if err.Error() != constants.ErrMsgIdentityNotFound {
+		return nil, err
+	}
+
+	// Only an email the provider vouches for can pick the account
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.New(constants.ErrMsgProviderEmailNotVerified)
+	}
+
+	user, err := s.userService.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil && !user.EmailVerified():
+		// Whoever registered the email may not own it, linking would let
+		// them keep a password to the provider user's account
+		return nil, errors.New(constants.ErrMsgIdentityNotLinked)
+	case err != nil && err.Error() == constants.ErrMsgUserNotFound:
+		name := claims.Name
+		if name == "" {
+			name = claims.Email
+		}
+
+		user, err = s.userService.Provision(ctx, name, claims.Email)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	if _, err := s.link(ctx, user.ID, login.Provider, claims); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *federationService) BeginLink(ctx context.Context, provider, userID string) (*dto.FederatedLoginDTO, error) {
+	return s.begin(ctx, provider, userID)
+}
+
+func (s *federationService) FinishLink(ctx context.Context, userID, flow, code, state string) (*entity.Identity, error) {
+	login, claims, err := s.finish(ctx, flow, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if login.LinkUserID != userID {
+		return nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	identity, err := s.repo.GetBySubject(ctx, login.Provider, claims.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return nil, errors.New(constants.ErrMsgIdentityInUse)
+		}
+		return identity, nil
+	}
+
+	if err.Error() != constants.ErrMsgIdentityNotFound {
+		return nil, err
+	}
+
+	// Both logins were just proven, the emails don't need to match
+	return s.link(ctx, userID, login.Provider, claims)
+}
+
+func (s *federationService) List(ctx context.Context, userID string) ([]*entity.Identity, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *federationService) Unlink(ctx context.Context, userID, id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return errors.New(constants.ErrMsgIdentityNotFound)
+	}
+
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *federationService) begin(ctx context.Context, name, linkUserID string) (*dto.FederatedLoginDTO, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, errors.New(constants.ErrMsgUnknownProvider)
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := randomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = secret
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	flow, err := s.signer.Sign(federatedLoginClaims{
+		Type:       TokenTypeFederatedLogin,
+		Provider:   name,
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		LinkUserID: linkUserID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(federatedLoginTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.FederatedLoginDTO{AuthorizationURL: authorizationURL, Flow: flow}, nil
+}
+
+// finish burns the flow token, checks the state the provider redirected
+// with and redeems the code.
+func (s *federationService) finish(ctx context.Context, flow, code, state string) (*federatedLoginClaims, *sso.Claims, error) {
+	login, err := s.consumeFlow(ctx, flow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(state), []byte(login.State)) != 1 {
+		return nil, nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	provider, ok := s.providers[login.Provider]
+	if !ok {
+		return nil, nil, errors.New(constants.ErrMsgUnknownProvider)
+	}
+
+	claims, err := provider.Exchange(ctx, code, login.Verifier, login.Nonce)
+	if err != nil {
+		s.log.Printf("FEDERATION SERVICE: %s login rejected: %s", login.Provider, err.Error())
+		return nil, nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	return login, claims, nil
+}
+
+func (s *federationService) consumeFlow(ctx context.Context, flow string) (*federatedLoginClaims, error) {
+	token, err := jwt.ParseWithClaims(flow, &federatedLoginClaims{}, s.signer.Keyfunc)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	claims, ok := token.Claims.(*federatedLoginClaims)
+	if !ok || !token.Valid || claims.Type != TokenTypeFederatedLogin || claims.ID == "" {
+		return nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	// Only one of concurrent uses of the token gets through
+	consumed, err := s.revocations.ConsumeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	if !consumed {
+		return nil, errors.New(constants.ErrMsgInvalidFederatedLogin)
+	}
+
+	return claims, nil
+}
+
+func (s *federationService) link(ctx context.Context, userID, provider string, claims *sso.Claims) (*entity.Identity, error) {
+	now := time.Now()
+	identity := &entity.Identity{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+
+	if err := s.repo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
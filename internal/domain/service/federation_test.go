@@ -0,0 +1,292 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/internal/infra/sso"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/jwks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdP is an in-process OpenID Connect provider. Logins skip its UI: the
+// test picks the identity and gets the code the browser would bring back.
+type fakeIdP struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]fakeGrant
+}
+
+type fakeGrant struct {
+	challenge string
+	claims    sso.Claims
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	idp := &fakeIdP{key: key, codes: map[string]fakeGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		public, _ := jwks.NewKey("fake", "ES256", key.Public())
+		json.NewEncoder(w).Encode(jwks.Set{Keys: []jwks.Key{public}})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, _ := r.BasicAuth()
+
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || clientID != "golerplate" || secret != "secret" || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, grant.claims)
+	token.Header["kid"] = "fake"
+	idToken, _ := token.SignedString(idp.key)
+
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+}
+
+// login logs identity in through authorizationURL and returns the code and
+// state of the redirect.
+func (idp *fakeIdP) login(t *testing.T, authorizationURL string, identity sso.Claims) (string, string) {
+	target, err := url.Parse(authorizationURL)
+	require.NoError(t, err)
+	query := target.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	identity.Nonce = query.Get("nonce")
+	identity.Issuer = idp.server.URL
+	identity.Audience = jwt.ClaimStrings{query.Get("client_id")}
+	identity.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+
+	code := "code-" + query.Get("state")
+	idp.mu.Lock()
+	idp.codes[code] = fakeGrant{challenge: query.Get("code_challenge"), claims: identity}
+	idp.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+// memoryIdentities stores identities by provider and subject.
+type memoryIdentities map[string]*entity.Identity
+
+func (m memoryIdentities) Create(ctx context.Context, identity *entity.Identity) error {
+	if _, ok := m[identity.Provider+"|"+identity.Subject]; ok {
+		return errors.New(constants.ErrMsgIdentityInUse)
+	}
+	m[identity.Provider+"|"+identity.Subject] = identity
+	return nil
+}
+
+func (m memoryIdentities) GetBySubject(ctx context.Context, provider, subject string) (*entity.Identity, error) {
+	if identity, ok := m[provider+"|"+subject]; ok {
+		return identity, nil
+	}
+	return nil, errors.New(constants.ErrMsgIdentityNotFound)
+}
+
+func (m memoryIdentities) ListByUser(ctx context.Context, userID string) ([]*entity.Identity, error) {
+	identities := []*entity.Identity{}
+	for _, identity := range m {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (m memoryIdentities) Touch(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m memoryIdentities) Delete(ctx context.Context, userID, id string) error {
+	for key, identity := range m {
+		if identity.ID == id && identity.UserID == userID {
+			delete(m, key)
+			return nil
+		}
+	}
+	return errors.New(constants.ErrMsgIdentityNotFound)
+}
+
+func TestFederatedLogin(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdP(t)
+	verifiedAt := time.Now()
+
+	newFederation := func(userRepo *MockUserRepository) (service.FederationService, memoryIdentities) {
+		provider := sso.NewProvider(sso.Config{
+			Name:         "corp",
+			Issuer:       idp.server.URL,
+			ClientID:     "golerplate",
+			ClientSecret: "secret",
+			Scopes:       []string{"openid", "email", "profile"},
+			RedirectURL:  "http://app/login/sso/callback",
+		})
+		identities := memoryIdentities{}
+		return service.NewFederationService([]sso.Provider{provider}, identities, newUserService(userRepo),
+			service.NewHMACSigner("", "refresh"), repository.NewMemoryRevocationStore()), identities
+	}
+
+	identity := sso.Claims{Email: "jane@corp.example", EmailVerified: true, Name: "Jane Doe", RegisteredClaims: jwt.RegisteredClaims{Subject: "corp-123"}}
+
+	login := func(t *testing.T, federation service.FederationService, identity sso.Claims) (*entity.User, error) {
+		begin, err := federation.BeginLogin(ctx, "corp")
+		require.NoError(t, err)
+
+		code, state := idp.login(t, begin.AuthorizationURL, identity)
+		return federation.FinishLogin(ctx, begin.Flow, code, state)
+	}
+
+	t.Run("New users are provisioned, then found by identity", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, identity.Email).Return(nil, errors.New(constants.ErrMsgUserNotFound)).Once()
+		var created *entity.User
+		provisioned := &entity.User{ID: "new-user", Email: identity.Email, EmailVerifiedAt: &verifiedAt}
+		userRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).
+			Run(func(args mock.Arguments) { created = args.Get(1).(*entity.User) }).
+			Return(provisioned, nil).Once()
+
+		federation, identities := newFederation(userRepo)
+
+		user, err := login(t, federation, identity)
+		require.NoError(t, err)
+		assert.Equal(t, "new-user", user.ID)
+		assert.Equal(t, "Jane Doe", created.FullName)
+		assert.True(t, created.EmailVerified())
+		assert.Empty(t, created.Password)
+		require.Contains(t, identities, "corp|corp-123")
+		assert.Equal(t, "new-user", identities["corp|corp-123"].UserID)
+
+		// The identity is enough next time, even once the email changed
+		userRepo.On("GetByID", mock.Anything, "new-user").Return(provisioned, nil)
+		renamed := identity
+		renamed.Email = "jane.doe@corp.example"
+		user, err = login(t, federation, renamed)
+		require.NoError(t, err)
+		assert.Equal(t, "new-user", user.ID)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Verified accounts are linked by email", func(t *testing.T) {
+		existing := &entity.User{ID: "user-id", Email: identity.Email, EmailVerifiedAt: &verifiedAt}
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, identity.Email).Return(existing, nil)
+
+		federation, identities := newFederation(userRepo)
+
+		user, err := login(t, federation, identity)
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, user.ID)
+		assert.Equal(t, existing.ID, identities["corp|corp-123"].UserID)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unverified accounts aren't taken over", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, identity.Email).Return(&entity.User{ID: "squatter", Email: identity.Email}, nil)
+
+		federation, identities := newFederation(userRepo)
+
+		_, err := login(t, federation, identity)
+		assert.EqualError(t, err, constants.ErrMsgIdentityNotLinked)
+		assert.Empty(t, identities)
+	})
+
+	t.Run("Emails the provider didn't verify are refused", func(t *testing.T) {
+		federation, _ := newFederation(new(MockUserRepository))
+
+		unverified := identity
+		unverified.EmailVerified = false
+		_, err := login(t, federation, unverified)
+		assert.EqualError(t, err, constants.ErrMsgProviderEmailNotVerified)
+	})
+
+	t.Run("A flow only works once and with its state", func(t *testing.T) {
+		federation, _ := newFederation(new(MockUserRepository))
+
+		begin, err := federation.BeginLogin(ctx, "corp")
+		require.NoError(t, err)
+		code, _ := idp.login(t, begin.AuthorizationURL, identity)
+
+		_, err = federation.FinishLogin(ctx, begin.Flow, code, "forged-state")
+		assert.EqualError(t, err, constants.ErrMsgInvalidFederatedLogin)
+
+		_, err = federation.BeginLogin(ctx, "unknown")
+		assert.EqualError(t, err, constants.ErrMsgUnknownProvider)
+	})
+
+	t.Run("Logged in users link identities to their account", func(t *testing.T) {
+		federation, identities := newFederation(new(MockUserRepository))
+
+		begin, err := federation.BeginLink(ctx, "corp", "user-id")
+		require.NoError(t, err)
+		code, state := idp.login(t, begin.AuthorizationURL, identity)
+
+		// A link flow doesn't log anybody in
+		linked, err := federation.FinishLink(ctx, "user-id", begin.Flow, code, state)
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", linked.UserID)
+		assert.Len(t, identities, 1)
+
+		begin, err = federation.BeginLink(ctx, "corp", "other-user")
+		require.NoError(t, err)
+		code, state = idp.login(t, begin.AuthorizationURL, identity)
+
+		_, err = federation.FinishLink(ctx, "other-user", begin.Flow, code, state)
+		assert.EqualError(t, err, constants.ErrMsgIdentityInUse)
+
+		begin, err = federation.BeginLink(ctx, "corp", "user-id")
+		require.NoError(t, err)
+		code, state = idp.login(t, begin.AuthorizationURL, identity)
+
+		_, err = federation.FinishLogin(ctx, begin.Flow, code, state)
+		assert.EqualError(t, err, constants.ErrMsgInvalidFederatedLogin)
+	})
+}
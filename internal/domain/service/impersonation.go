@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// impersonationAuditLimit caps how many requests History returns.
+const impersonationAuditLimit = 200
+
+type ImpersonationService interface {
+	// Impersonate issues the actor a short lived access token acting as the
+	// user. Impersonation tokens can't impersonate in turn.
+	Impersonate(ctx context.Context, actor *Claims, userID string) (*dto.ImpersonationResponseDTO, error)
+	// Record audits a request made with an impersonation token.
+	Record(ctx context.Context, entry *entity.ImpersonationAudit) error
+	// History returns the latest requests made as the user.
+	History(ctx context.Context, userID string) ([]*entity.ImpersonationAudit, error)
+}
+
+type impersonationService struct {
+	repo        repository.ImpersonationAuditRepository
+	userService UserService
+	authService AuthService
+	log         *log.Logger
+}
+
+func NewImpersonationService(r repository.ImpersonationAuditRepository, us UserService, as AuthService) *impersonationService {
+	return &impersonationService{
+		repo:        r,
+		userService: us,
+		authService: as,
+		log:         log.Default(),
+	}
+}
+
+func (s *impersonationService) Impersonate(ctx context.Context, actor *Claims, userID string) (*dto.ImpersonationResponseDTO, error) {
+	if actor.Act != nil {
+		return nil, errors.New(constants.ErrMsgImpersonating)
+	}
+
+	if actor.UserID == userID {
+		return nil, errors.New(constants.ErrMsgCannotImpersonateSelf)
+	}
+
+	if uuid.Validate(userID) != nil {
+		return nil, errors.New(constants.ErrMsgUserNotFound)
+	}
+
+	user, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := s.authService.ImpersonationToken(user, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Printf("SECURITY: impersonation started (actor=%s user=%s)", actor.UserID, user.ID)
+
+	return &dto.ImpersonationResponseDTO{AccessToken: token, ExpiresAt: expiresAt}, nil
+}
+
+func (s *impersonationService) Record(ctx context.Context, entry *entity.ImpersonationAudit) error {
+	entry.ID = uuid.NewString()
+	return s.repo.Create(ctx, entry)
+}
+
+func (s *impersonationService) History(ctx context.Context, userID string) ([]*entity.ImpersonationAudit, error) {
+	if uuid.Validate(userID) != nil {
+		return []*entity.ImpersonationAudit{}, nil
+	}
+
+	return s.repo.ListByUser(ctx, userID, impersonationAuditLimit)
+}
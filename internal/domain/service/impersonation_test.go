@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryImpersonationAudit keeps audited requests in insertion order.
+type memoryImpersonationAudit struct {
+	entries []*entity.ImpersonationAudit
+}
+
+func (m *memoryImpersonationAudit) Create(ctx context.Context, entry *entity.ImpersonationAudit) error {
+	entry.CreatedAt = time.Now()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryImpersonationAudit) ListByUser(ctx context.Context, userID string, limit int) ([]*entity.ImpersonationAudit, error) {
+	entries := []*entity.ImpersonationAudit{}
+	for i := len(m.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if m.entries[i].UserID == userID {
+			entries = append(entries, m.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func TestImpersonation(t *testing.T) {
+	ctx := context.Background()
+	adminID, userID := uuid.NewString(), uuid.NewString()
+	admin := &service.Claims{UserID: adminID, Type: service.TokenTypeAccess, Roles: []string{entity.RoleAdmin}}
+
+	newImpersonation := func(userRepo *MockUserRepository) (service.ImpersonationService, *memoryImpersonationAudit) {
+		audit := &memoryImpersonationAudit{}
+		authService := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository))
+		return service.NewImpersonationService(audit, newUserService(userRepo), authService), audit
+	}
+
+	t.Run("Issues a short lived token naming the admin", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+		impersonation, _ := newImpersonation(userRepo)
+
+		issued, err := impersonation.Impersonate(ctx, admin, userID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), issued.ExpiresAt, time.Minute)
+
+		claims := &service.Claims{}
+		_, err = jwt.ParseWithClaims(issued.AccessToken, claims, service.NewHMACSigner("", "access").Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+		assert.Equal(t, service.TokenTypeAccess, claims.Type)
+		require.NotNil(t, claims.Act)
+		assert.Equal(t, adminID, claims.Act.UserID)
+		assert.Empty(t, claims.Roles)
+		assert.Empty(t, claims.SessionID)
+		assert.Nil(t, claims.AuthTime)
+	})
+
+	t.Run("Refuses to impersonate yourself or from an impersonation token", func(t *testing.T) {
+		impersonation, _ := newImpersonation(new(MockUserRepository))
+
+		_, err := impersonation.Impersonate(ctx, admin, adminID)
+		assert.EqualError(t, err, constants.ErrMsgCannotImpersonateSelf)
+
+		impersonating := &service.Claims{UserID: userID, Type: service.TokenTypeAccess, Act: &service.Actor{UserID: adminID}}
+		_, err = impersonation.Impersonate(ctx, impersonating, uuid.NewString())
+		assert.EqualError(t, err, constants.ErrMsgImpersonating)
+	})
+
+	t.Run("Unknown users are not found", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, userID).Return(nil, errors.New(constants.ErrMsgUserNotFound))
+		impersonation, _ := newImpersonation(userRepo)
+
+		_, err := impersonation.Impersonate(ctx, admin, userID)
+		assert.EqualError(t, err, constants.ErrMsgUserNotFound)
+
+		_, err = impersonation.Impersonate(ctx, admin, "not-a-uuid")
+		assert.EqualError(t, err, constants.ErrMsgUserNotFound)
+	})
+
+	t.Run("Audited requests are listed newest first", func(t *testing.T) {
+		impersonation, _ := newImpersonation(new(MockUserRepository))
+
+		for _, path := range []string{"/api/sessions", "/api/tokens"} {
+			entry := &entity.ImpersonationAudit{ActorID: adminID, UserID: userID, Method: "GET", Path: path, Status: 200}
+			require.NoError(t, impersonation.Record(ctx, entry))
+			assert.NotEmpty(t, entry.ID)
+		}
+
+		entries, err := impersonation.History(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "/api/tokens", entries[0].Path)
+
+		entries, err = impersonation.History(ctx, "not-a-uuid")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// Token types reported by introspection, named after the RFC 7009 hints.
+const (
+	IntrospectedAccessToken  = "access_token"
+	IntrospectedRefreshToken = "refresh_token"
+	IntrospectedAPIKey       = "api_key"
+)
+
+// ScopeIntrospect is registered for the clients, such as resource servers,
+// allowed to introspect tokens issued to anybody else.
+const ScopeIntrospect = "introspect"
+
+type IntrospectionService interface {
+	// Introspect tells whether a token would be accepted right now, as in
+	// RFC 7662. A token that isn't only comes back with Active false, errors
+	// are left for failures to find out. Unless caller was registered with
+	// ScopeIntrospect, only the tokens issued to it are reported active.
+	Introspect(ctx context.Context, caller *entity.OAuthClient, token string) (*dto.IntrospectionResponseDTO, error)
+}
+
+type introspectionService struct {
+	accessSigner  Signer
+	refreshSigner Signer
+	authService   AuthService
+	refreshRepo   repository.RefreshTokenRepository
+	sessionRepo   repository.SessionRepository
+	apiKeyService APIKeyService
+}
+
+func NewIntrospectionService(accessSigner, refreshSigner Signer, as AuthService, refreshRepo repository.RefreshTokenRepository, sessionRepo repository.SessionRepository, ks APIKeyService) *introspectionService {
+	return &introspectionService{
+		accessSigner:  accessSigner,
+		refreshSigner: refreshSigner,
+		authService:   as,
+		refreshRepo:   refreshRepo,
+		sessionRepo:   sessionRepo,
+		apiKeyService: ks,
+	}
+}
+
+func inactive() *dto.IntrospectionResponseDTO {
+	return &dto.IntrospectionResponseDTO{Active: false}
+}
+
+func (s *introspectionService) Introspect(ctx context.Context, caller *entity.OAuthClient, token string) (*dto.IntrospectionResponseDTO, error) {
+	response, err := s.introspect(ctx, token)
+	if err != nil || !response.Active {
+		return response, err
+	}
+
+	// RFC 7662 has tokens the caller may not see reported as inactive
+	if response.ClientID != caller.ID && !caller.AllowsScope(ScopeIntrospect) {
+		return inactive(), nil
+	}
+
+	return response, nil
+}
+
+func (s *introspectionService) introspect(ctx context.Context, token string) (*dto.IntrospectionResponseDTO, error) {
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return s.apiKey(ctx, token)
+	}
+
+	if claims, ok := parseClaims(token, s.accessSigner, TokenTypeAccess); ok {
+		return s.claims(ctx, claims, IntrospectedAccessToken)
+	}
+
+	if claims, ok := parseClaims(token, s.accessSigner, TokenTypeClient); ok {
+		return s.claims(ctx, claims, IntrospectedAccessToken)
+	}
+
+	if claims, ok := parseClaims(token, s.refreshSigner, TokenTypeRefresh); ok {
+		// Rotated and revoked refresh tokens still carry a valid signature
+		record, err := s.refreshRepo.GetByID(ctx, claims.ID)
+		if err != nil {
+			if err.Error() == constants.ErrMsgInvalidToken {
+				return inactive(), nil
+			}
+			return nil, err
+		}
+
+		if record.UsedAt != nil || record.RevokedAt != nil || record.UserID != claims.UserID {
+			return inactive(), nil
+		}
+
+		return s.claims(ctx, claims, IntrospectedRefreshToken)
+	}
+
+	return inactive(), nil
+}
+
+// claims checks a signed token against revocations and its session.
+func (s *introspectionService) claims(ctx context.Context, claims *Claims, tokenType string) (*dto.IntrospectionResponseDTO, error) {
+	revoked, err := s.authService.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return inactive(), nil
+	}
+
+	// Tokens from before sessions were tracked have none to check
+	if claims.SessionID != "" {
+		session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+		if err != nil {
+			if err.Error() == constants.ErrMsgSessionNotFound {
+				return inactive(), nil
+			}
+			return nil, err
+		}
+
+		if session.RevokedAt != nil || !time.Now().Before(session.ExpiresAt) || session.UserID != claims.UserID {
+			return inactive(), nil
+		}
+	}
+
+	response := &dto.IntrospectionResponseDTO{
+		Active:    true,
+		Sub:       claims.UserID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+		TokenType: tokenType,
+		Jti:       claims.ID,
+		SessionID: claims.SessionID,
+	}
+	if claims.Type == TokenTypeClient {
+		response.Sub = claims.Subject
+	}
+	if claims.Act != nil {
+		response.Act = &dto.IntrospectionActorDTO{Sub: claims.Act.UserID}
+	}
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+
+	return response, nil
+}
+
+func (s *introspectionService) apiKey(ctx context.Context, token string) (*dto.IntrospectionResponseDTO, error) {
+	key, err := s.apiKeyService.Authenticate(ctx, token)
+	if err != nil {
+		if err.Error() == constants.ErrMsgInvalidAPIKey {
+			return inactive(), nil
+		}
+		return nil, err
+	}
+
+	return &dto.IntrospectionResponseDTO{
+		Active:    true,
+		Sub:       key.UserID,
+		Scope:     strings.Join(key.Scopes, " "),
+		TokenType: IntrospectedAPIKey,
+		Exp:       key.ExpiresAt.Unix(),
+		Iat:       key.CreatedAt.Unix(),
+		Jti:       key.ID,
+	}, nil
+}
+
+// parseClaims reports whether token was signed by signer, hasn't expired and
+// is of tokenType.
+func parseClaims(token string, signer Signer, tokenType string) (*Claims, bool) {
+	parsed, err := jwt.ParseWithClaims(token, &Claims{}, signer.Keyfunc)
+	if err != nil {
+		return nil, false
+	}
+
+	claims, ok := parsed.Claims.(*Claims)
+	if !ok || !parsed.Valid || claims.Type != tokenType || claims.ID == "" {
+		return nil, false
+	}
+
+	// Client tokens are the only ones without a user
+	if (tokenType == TokenTypeClient) != (claims.UserID == "") {
+		return nil, false
+	}
+
+	return claims, true
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospect(t *testing.T) {
+	ctx := context.Background()
+	user := &entity.User{ID: "user-id"}
+	resourceServer := &entity.OAuthClient{ID: "resource-server", Scopes: []string{service.ScopeIntrospect}}
+
+	// login issues a pair and returns the records stored for it.
+	login := func(t *testing.T) (service.IntrospectionService, string, string, *entity.Session, *entity.RefreshToken) {
+		refreshRepo := new(MockRefreshTokenRepository)
+		sessionRepo := new(MockSessionRepository)
+
+		var session *entity.Session
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).
+			Run(func(args mock.Arguments) { session = args.Get(1).(*entity.Session) }).
+			Return(nil)
+		var record *entity.RefreshToken
+		refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).
+			Run(func(args mock.Arguments) { record = args.Get(1).(*entity.RefreshToken) }).
+			Return(nil)
+
+		authService := newAuthService("access", "refresh", refreshRepo, sessionRepo)
+		pair, err := authService.GenerateToken(ctx, user, service.SessionMeta{})
+		require.NoError(t, err)
+
+		refreshRepo.On("GetByID", mock.Anything, record.ID).Return(record, nil)
+		sessionRepo.On("GetByID", mock.Anything, session.ID).Return(session, nil)
+
+		apiKeyRepo := new(MockAPIKeyRepository)
+		apiKeyRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New(constants.ErrMsgAPIKeyNotFound)).Maybe()
+
+		introspection := service.NewIntrospectionService(service.NewHMACSigner("", "access"), service.NewHMACSigner("", "refresh"),
+			authService, refreshRepo, sessionRepo, service.NewAPIKeyService(apiKeyRepo))
+
+		return introspection, pair.AccessToken, pair.RefreshToken, session, record
+	}
+
+	t.Run("Active tokens describe themselves", func(t *testing.T) {
+		introspection, access, refresh, session, _ := login(t)
+
+		got, err := introspection.Introspect(ctx, resourceServer, access)
+		require.NoError(t, err)
+		assert.True(t, got.Active)
+		assert.Equal(t, user.ID, got.Sub)
+		assert.Equal(t, session.ID, got.SessionID)
+		assert.Equal(t, service.IntrospectedAccessToken, got.TokenType)
+		assert.Greater(t, got.Exp, time.Now().Unix())
+
+		got, err = introspection.Introspect(ctx, resourceServer, refresh)
+		require.NoError(t, err)
+		assert.True(t, got.Active)
+		assert.Equal(t, service.IntrospectedRefreshToken, got.TokenType)
+	})
+
+	t.Run("A revoked session takes its tokens down", func(t *testing.T) {
+		introspection, access, refresh, session, _ := login(t)
+
+		revokedAt := time.Now()
+		session.RevokedAt = &revokedAt
+
+		for _, token := range []string{access, refresh} {
+			got, err := introspection.Introspect(ctx, resourceServer, token)
+			require.NoError(t, err)
+			assert.False(t, got.Active)
+			assert.Empty(t, got.Sub)
+		}
+	})
+
+	t.Run("A rotated refresh token is inactive", func(t *testing.T) {
+		introspection, _, refresh, _, record := login(t)
+
+		usedAt := time.Now()
+		record.UsedAt = &usedAt
+
+		got, err := introspection.Introspect(ctx, resourceServer, refresh)
+		require.NoError(t, err)
+		assert.False(t, got.Active)
+	})
+
+	t.Run("Other tokens are inactive", func(t *testing.T) {
+		introspection, _, _, _, _ := login(t)
+
+		mfaToken, err := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)).MFAToken(user)
+		require.NoError(t, err)
+
+		for _, token := range []string{mfaToken, "not-a-token", service.APIKeyPrefix + "short"} {
+			got, err := introspection.Introspect(ctx, resourceServer, token)
+			require.NoError(t, err)
+			assert.False(t, got.Active, token)
+		}
+	})
+
+	t.Run("Clients only see their own tokens", func(t *testing.T) {
+		introspection, access, _, _, _ := login(t)
+		client := &entity.OAuthClient{ID: "client-id", Scopes: []string{service.ScopeOpenID}}
+
+		got, err := introspection.Introspect(ctx, client, access)
+		require.NoError(t, err)
+		assert.False(t, got.Active)
+		assert.Empty(t, got.Sub)
+
+		own, err := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)).ClientToken(client, "")
+		require.NoError(t, err)
+
+		got, err = introspection.Introspect(ctx, client, own)
+		require.NoError(t, err)
+		assert.True(t, got.Active)
+		assert.Equal(t, client.ID, got.ClientID)
+	})
+
+	t.Run("Impersonation tokens name the actor", func(t *testing.T) {
+		introspection, _, _, _, _ := login(t)
+
+		token, _, err := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)).ImpersonationToken(user, "admin-id")
+		require.NoError(t, err)
+
+		got, err := introspection.Introspect(ctx, resourceServer, token)
+		require.NoError(t, err)
+		assert.True(t, got.Active)
+		if assert.NotNil(t, got.Act) {
+			assert.Equal(t, "admin-id", got.Act.Sub)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/jwks"
+)
+
+type keyRingEntry struct {
+	signer Signer
+	// retiredAt is zero for the active key
+	retiredAt time.Time
+}
+
+// RetiredKey is a key no longer signing, with when it stopped.
+type RetiredKey struct {
+	Signer Signer
+	// RetiredAt is when the grace period started, zero for the first Load
+	// the key was seen in
+	RetiredAt time.Time
+}
+
+// KeyRing signs with a single active key while still verifying tokens signed
+// by keys it retired less than grace ago. Keys are told apart by their kid.
+type KeyRing struct {
+	mu     sync.RWMutex
+	grace  time.Duration
+	active Signer
+	keys   map[string]*keyRingEntry
+}
+
+func NewKeyRing(grace time.Duration) *KeyRing {
+	return &KeyRing{
+		grace: grace,
+		keys:  make(map[string]*keyRingEntry),
+	}
+}
+
+// Load swaps the key set. A key without a retirement time keeps the one it had
+// on previous loads, keys missing from the new set stop being accepted at once.
+func (r *KeyRing) Load(active Signer, retired ...RetiredKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	keys := make(map[string]*keyRingEntry, len(retired)+1)
+
+	for _, key := range retired {
+		entry := &keyRingEntry{signer: key.Signer, retiredAt: key.RetiredAt}
+		if entry.retiredAt.IsZero() {
+			entry.retiredAt = now
+			if previous, ok := r.keys[key.Signer.KeyID()]; ok && !previous.retiredAt.IsZero() {
+				entry.retiredAt = previous.retiredAt
+			}
+		}
+		keys[key.Signer.KeyID()] = entry
+	}
+
+	keys[active.KeyID()] = &keyRingEntry{signer: active}
+
+	r.active = active
+	r.keys = keys
+}
+
+func (r *KeyRing) KeyID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.active.KeyID()
+}
+
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	r.mu.RLock()
+	active := r.active
+	r.mu.RUnlock()
+
+	return active.Sign(claims)
+}
+
+func (r *KeyRing) Keyfunc(token *jwt.Token) (any, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kid, ok := token.Header["kid"].(string)
+
+	// Tokens minted before kids were introduced can only come from the active key
+	if !ok {
+		return r.active.Keyfunc(token)
+	}
+
+	entry, ok := r.keys[kid]
+	if !ok || r.expired(entry) {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	return entry.signer.Keyfunc(token)
+}
+
+func (r *KeyRing) JWKS() jwks.Set {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := jwks.Set{Keys: []jwks.Key{}}
+	for _, entry := range r.keys {
+		if r.expired(entry) {
+			continue
+		}
+		set.Keys = append(set.Keys, entry.signer.JWKS().Keys...)
+	}
+
+	return set
+}
+
+func (r *KeyRing) expired(entry *keyRingEntry) bool {
+	return !entry.retiredAt.IsZero() && time.Since(entry.retiredAt) >= r.grace
+}
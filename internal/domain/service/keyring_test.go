@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRingRotation(t *testing.T) {
+	oldKey := service.NewHMACSigner("old", "old-secret")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := service.NewSigner("new", ecKey)
+	require.NoError(t, err)
+
+	sign := func(t *testing.T, signer service.Signer) string {
+		signed, err := signer.Sign(service.Claims{UserID: "user-id"})
+		require.NoError(t, err)
+		return signed
+	}
+
+	t.Run("Retired key verifies within the grace window", func(t *testing.T) {
+		ring := service.NewKeyRing(time.Hour)
+		ring.Load(oldKey)
+		oldToken := sign(t, ring)
+
+		ring.Load(newKey, service.RetiredKey{Signer: oldKey})
+		newToken := sign(t, ring)
+
+		_, err := jwt.ParseWithClaims(oldToken, &service.Claims{}, ring.Keyfunc)
+		assert.NoError(t, err)
+
+		token, err := jwt.ParseWithClaims(newToken, &service.Claims{}, ring.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, "new", token.Header["kid"])
+		assert.Len(t, ring.JWKS().Keys, 1)
+	})
+
+	t.Run("Retired key is rejected after the grace window", func(t *testing.T) {
+		ring := service.NewKeyRing(0)
+		ring.Load(oldKey)
+		oldToken := sign(t, ring)
+
+		ring.Load(newKey, service.RetiredKey{Signer: oldKey})
+
+		_, err := jwt.ParseWithClaims(oldToken, &service.Claims{}, ring.Keyfunc)
+		assert.Error(t, err)
+	})
+
+	t.Run("Removed key is rejected", func(t *testing.T) {
+		ring := service.NewKeyRing(time.Hour)
+		ring.Load(oldKey)
+		oldToken := sign(t, ring)
+
+		ring.Load(newKey)
+
+		_, err := jwt.ParseWithClaims(oldToken, &service.Claims{}, ring.Keyfunc)
+		assert.Error(t, err)
+	})
+
+	t.Run("Alg of a known kid cannot be swapped", func(t *testing.T) {
+		ring := service.NewKeyRing(time.Hour)
+		ring.Load(newKey, service.RetiredKey{Signer: oldKey})
+
+		// HMAC token claiming the asymmetric kid
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, service.Claims{UserID: "user-id"})
+		forged.Header["kid"] = "new"
+		signed, err := forged.SignedString([]byte("old-secret"))
+		require.NoError(t, err)
+
+		_, err = jwt.ParseWithClaims(signed, &service.Claims{}, ring.Keyfunc)
+		assert.Error(t, err)
+	})
+
+	t.Run("A restart doesn't extend the grace window", func(t *testing.T) {
+		ring := service.NewKeyRing(time.Hour)
+		ring.Load(oldKey)
+		oldToken := sign(t, ring)
+
+		ring.Load(newKey, service.RetiredKey{Signer: oldKey, RetiredAt: time.Now().Add(-30 * time.Minute)})
+		_, err := jwt.ParseWithClaims(oldToken, &service.Claims{}, ring.Keyfunc)
+		assert.NoError(t, err)
+
+		// The same configuration loaded after the window is over
+		restarted := service.NewKeyRing(time.Hour)
+		restarted.Load(newKey, service.RetiredKey{Signer: oldKey, RetiredAt: time.Now().Add(-2 * time.Hour)})
+		_, err = jwt.ParseWithClaims(oldToken, &service.Claims{}, restarted.Keyfunc)
+		assert.Error(t, err)
+		assert.Len(t, restarted.JWKS().Keys, 1)
+	})
+}
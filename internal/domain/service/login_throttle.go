@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ThrottlePolicy describes how failed logins slow down and lock a key.
+type ThrottlePolicy struct {
+	// FreeAttempts are the failures allowed before any delay.
+	FreeAttempts int
+	// BaseDelay doubles with every failure past FreeAttempts, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockThreshold failures lock the key for LockDuration.
+	LockThreshold int
+	LockDuration  time.Duration
+	// Window is how long a failure is remembered.
+	Window time.Duration
+}
+
+var (
+	DefaultAccountThrottle = ThrottlePolicy{
+		FreeAttempts:  3,
+		BaseDelay:     time.Second,
+		MaxDelay:      time.Minute,
+		LockThreshold: 10,
+		LockDuration:  15 * time.Minute,
+		Window:        15 * time.Minute,
+	}
+	// DefaultIPThrottle is looser, many users can share an address.
+	DefaultIPThrottle = ThrottlePolicy{
+		FreeAttempts:  10,
+		BaseDelay:     time.Second,
+		MaxDelay:      time.Minute,
+		LockThreshold: 50,
+		LockDuration:  15 * time.Minute,
+		Window:        15 * time.Minute,
+	}
+)
+
+// delay is how long to wait after the given number of failures.
+func (p ThrottlePolicy) delay(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+// An empty email only involves the IP, for logins that don't start with one.
+type LoginThrottleService interface {
+	// Check returns how long the caller must wait before a login for the
+	// email from the IP may be attempted, zero when it may go ahead.
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	// Attempt is Check for a login about to check credentials. The attempt
+	// is counted as failed right away, so concurrent guesses can't all get
+	// past the lock threshold, and Failure, Success or Forgive must follow
+	// when it may go ahead.
+	Attempt(ctx context.Context, email, ip string) (time.Duration, error)
+	// Failure returns the wait a failed attempt triggers, and whether it
+	// locked the account or the IP.
+	Failure(ctx context.Context, email, ip string) (time.Duration, bool, error)
+	// Success forgets the failures of the account, and takes the attempt
+	// back from the IP.
+	Success(ctx context.Context, email, ip string) error
+	// Forgive takes an attempt back, for credentials that were right but
+	// don't end the login yet, such as a password followed by a code.
+	Forgive(ctx context.Context, email, ip string) error
+	// SendAttempt counts a login email, such as a link, about to be sent to
+	// the address and returns how long the caller must wait instead when
+	// too many were.
+	SendAttempt(ctx context.Context, email string) (time.Duration, error)
+	// TokenFailure counts a wrong code given with the pending token jti and
+	// returns how many there were so far.
+	TokenFailure(ctx context.Context, jti string) (int, error)
+	// Unlock clears the failures and lock of an account.
+	Unlock(ctx context.Context, email string) error
+}
+
+type loginThrottleService struct {
+	store    repository.LoginAttemptStore
+	account  ThrottlePolicy
+	ip       ThrottlePolicy
+	failures metric.Int64Counter
+	log      *log.Logger
+}
+
+func NewLoginThrottleService(store repository.LoginAttemptStore, account, ip ThrottlePolicy) *loginThrottleService {
+	failures, err := otel.Meter(constants.TRACER_NAME).Int64Counter("login_failures",
+		metric.WithDescription("Failed login attempts, locked tells the ones that locked an account or IP"))
+	if err != nil {
+		log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+
+	return &loginThrottleService{
+		store:    store,
+		account:  account,
+		ip:       ip,
+		failures: failures,
+		log:      log.Default(),
+	}
+}
+
+func (s *loginThrottleService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	return s.check(ctx, s.keys(email, ip))
+}
+
+func (s *loginThrottleService) Attempt(ctx context.Context, email, ip string) (time.Duration, error) {
+	return s.attempt(ctx, s.keys(email, ip))
+}
+
+func (s *loginThrottleService) SendAttempt(ctx context.Context, email string) (time.Duration, error) {
+	return s.attempt(ctx, map[string]ThrottlePolicy{"send:" + normalizeEmail(email): s.account})
+}
+
+func (s *loginThrottleService) check(ctx context.Context, keys map[string]ThrottlePolicy) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+
+	for key, policy := range keys {
+		attempts, err := s.store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		wait = max(wait, policy.wait(attempts, now))
+	}
+
+	return wait, nil
+}
+
+func (s *loginThrottleService) attempt(ctx context.Context, keys map[string]ThrottlePolicy) (time.Duration, error) {
+	wait, err := s.check(ctx, keys)
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+
+	now := time.Now()
+	for key, policy := range keys {
+		attempts, err := s.store.RecordFailure(ctx, key, now, policy.Window)
+		if err != nil {
+			return 0, err
+		}
+
+		// Decided on the count the store returned, the attempts that got
+		// in first take every try left
+		if attempts.Failures > policy.LockThreshold {
+			if _, err := s.lock(ctx, key, policy, attempts, now); err != nil {
+				return 0, err
+			}
+			wait = max(wait, policy.wait(attempts, now))
+		}
+	}
+
+	return wait, nil
+}
+
+func (s *loginThrottleService) Failure(ctx context.Context, email, ip string) (time.Duration, bool, error) {
+	now := time.Now()
+	var wait time.Duration
+	locked := false
+
+	for key, policy := range s.keys(email, ip) {
+		// Attempt already counted it
+		attempts, err := s.store.Get(ctx, key)
+		if err != nil {
+			return 0, false, err
+		}
+
+		keyLocked, err := s.lock(ctx, key, policy, attempts, now)
+		if err != nil {
+			return 0, false, err
+		}
+		locked = locked || keyLocked
+
+		wait = max(wait, policy.wait(attempts, now))
+	}
+
+	if s.failures != nil {
+		s.failures.Add(ctx, 1, metric.WithAttributes(attribute.Bool("locked", locked)))
+	}
+
+	return wait, locked, nil
+}
+
+func (s *loginThrottleService) Success(ctx context.Context, email, ip string) error {
+	if err := s.store.Reset(ctx, accountKey(email)); err != nil {
+		return err
+	}
+
+	return s.store.Forgive(ctx, "ip:"+ip)
+}
+
+func (s *loginThrottleService) Forgive(ctx context.Context, email, ip string) error {
+	for key := range s.keys(email, ip) {
+		if err := s.store.Forgive(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *loginThrottleService) TokenFailure(ctx context.Context, jti string) (int, error) {
+	attempts, err := s.store.RecordFailure(ctx, "token:"+jti, time.Now(), s.account.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	return attempts.Failures, nil
+}
+
+func (s *loginThrottleService) Unlock(ctx context.Context, email string) error {
+	return s.store.Reset(ctx, accountKey(email))
+}
+
+// lock locks key once its failures reach the threshold, unless it already
+// is, and reports whether it did.
+func (s *loginThrottleService) lock(ctx context.Context, key string, policy ThrottlePolicy, attempts *entity.LoginAttempts, now time.Time) (bool, error) {
+	if attempts == nil || attempts.Failures < policy.LockThreshold || (attempts.LockedUntil != nil && attempts.LockedUntil.After(now)) {
+		return false, nil
+	}
+
+	until := now.Add(policy.LockDuration)
+	if err := s.store.Lock(ctx, key, until); err != nil {
+		return false, err
+	}
+	attempts.LockedUntil = &until
+
+	s.log.Printf("SECURITY: %s locked until %s after %d attempts", key, until.Format(time.RFC3339), attempts.Failures)
+	return true, nil
+}
+
+func (s *loginThrottleService) keys(email, ip string) map[string]ThrottlePolicy {
+	keys := map[string]ThrottlePolicy{"ip:" + ip: s.ip}
+	if email != "" {
+		keys[accountKey(email)] = s.account
+	}
+
+	return keys
+}
+
+// wait is what is left of the lock or of the delay since the last failure.
+func (p ThrottlePolicy) wait(attempts *entity.LoginAttempts, now time.Time) time.Duration {
+	if attempts == nil {
+		return 0
+	}
+
+	if attempts.LockedUntil != nil && attempts.LockedUntil.After(now) {
+		return attempts.LockedUntil.Sub(now)
+	}
+
+	if attempts.LastFailureAt.Before(now.Add(-p.Window)) {
+		return 0
+	}
+
+	return max(0, attempts.LastFailureAt.Add(p.delay(attempts.Failures)).Sub(now))
+}
+
+func accountKey(email string) string {
+	return "account:" + normalizeEmail(email)
+}
+
+// normalizeEmail keeps keys from depending on how the email was typed.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
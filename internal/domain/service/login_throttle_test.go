@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	ctx := context.Background()
+
+	policy := service.ThrottlePolicy{
+		FreeAttempts:  2,
+		BaseDelay:     10 * time.Millisecond,
+		MaxDelay:      40 * time.Millisecond,
+		LockThreshold: 5,
+		LockDuration:  time.Hour,
+		Window:        time.Hour,
+	}
+	loose := service.ThrottlePolicy{FreeAttempts: 100, LockThreshold: 100, Window: time.Hour}
+
+	// fail makes an attempt that turns out wrong, and waits out the delay
+	// it triggers.
+	fail := func(t *testing.T, throttle service.LoginThrottleService, email, ip string) (time.Duration, bool) {
+		wait, err := throttle.Attempt(ctx, email, ip)
+		require.NoError(t, err)
+		require.Zero(t, wait)
+
+		wait, locked, err := throttle.Failure(ctx, email, ip)
+		require.NoError(t, err)
+		if !locked {
+			time.Sleep(wait)
+		}
+		return wait, locked
+	}
+
+	t.Run("Delays grow after the free attempts and end in a lock", func(t *testing.T) {
+		throttle := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), policy, loose)
+
+		var waits []time.Duration
+		var locks []bool
+		for range 5 {
+			wait, locked := fail(t, throttle, "user@example.com", "10.0.0.1")
+			waits = append(waits, wait.Round(10*time.Millisecond))
+			locks = append(locks, locked)
+		}
+
+		assert.Equal(t, time.Duration(0), waits[0])
+		assert.Equal(t, 10*time.Millisecond, waits[1])
+		assert.Equal(t, 20*time.Millisecond, waits[2])
+		assert.Equal(t, 40*time.Millisecond, waits[3])
+		assert.Equal(t, time.Hour, waits[4])
+		assert.Equal(t, []bool{false, false, false, false, true}, locks)
+
+		// The lock holds whatever the address or the casing
+		wait, err := throttle.Check(ctx, "USER@example.com", "10.0.0.2")
+		require.NoError(t, err)
+		assert.Greater(t, wait, 59*time.Minute)
+	})
+
+	t.Run("Unlock clears the lock", func(t *testing.T) {
+		throttle := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), policy, loose)
+
+		for range 5 {
+			fail(t, throttle, "user@example.com", "10.0.0.1")
+		}
+
+		require.NoError(t, throttle.Unlock(ctx, "user@example.com"))
+
+		wait, err := throttle.Check(ctx, "user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("Success forgets the account but not the IP", func(t *testing.T) {
+		throttle := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), loose, policy)
+
+		for range 3 {
+			fail(t, throttle, "user@example.com", "10.0.0.1")
+		}
+
+		wait, err := throttle.Attempt(ctx, "user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+		require.NoError(t, throttle.Success(ctx, "user@example.com", "10.0.0.1"))
+
+		wait, err = throttle.Check(ctx, "other@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		time.Sleep(wait)
+
+		// The fourth failure of the IP, the successful attempt isn't one
+		wait, locked := fail(t, throttle, "other@example.com", "10.0.0.1")
+		assert.False(t, locked)
+		assert.Equal(t, 40*time.Millisecond, wait.Round(10*time.Millisecond))
+	})
+
+	t.Run("Concurrent attempts don't get past the lock threshold", func(t *testing.T) {
+		// Without delays, which would hold back most of them anyway
+		lockOnly := service.ThrottlePolicy{FreeAttempts: 5, LockThreshold: 5, LockDuration: time.Hour, Window: time.Hour}
+		throttle := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), lockOnly, loose)
+
+		var wg sync.WaitGroup
+		var allowed atomic.Int32
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				wait, err := throttle.Attempt(ctx, "user@example.com", "10.0.0.1")
+				if assert.NoError(t, err) && wait == 0 {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(lockOnly.LockThreshold), allowed.Load())
+	})
+
+	t.Run("Sends are counted per email", func(t *testing.T) {
+		lockOnly := service.ThrottlePolicy{FreeAttempts: 3, LockThreshold: 3, LockDuration: time.Hour, Window: time.Hour}
+		throttle := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), lockOnly, loose)
+
+		for range 3 {
+			wait, err := throttle.SendAttempt(ctx, "user@example.com")
+			require.NoError(t, err)
+			require.Zero(t, wait)
+		}
+
+		wait, err := throttle.SendAttempt(ctx, " User@example.com")
+		require.NoError(t, err)
+		assert.Greater(t, wait, 59*time.Minute)
+
+		// Logins aren't held back by the links
+		wait, err = throttle.Check(ctx, "user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+
+		wait, err = throttle.SendAttempt(ctx, "other@example.com")
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("Token failures are counted per token", func(t *testing.T) {
+		throttle := service.NewLoginThrottleService(repository.NewMemoryLoginAttemptStore(), policy, loose)
+
+		for want := 1; want <= 3; want++ {
+			failures, err := throttle.TokenFailure(ctx, "jti")
+			require.NoError(t, err)
+			assert.Equal(t, want, failures)
+		}
+
+		failures, err := throttle.TokenFailure(ctx, "other-jti")
+		require.NoError(t, err)
+		assert.Equal(t, 1, failures)
+	})
+}
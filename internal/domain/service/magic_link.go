@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// TokenTypeMagicLink is the token emailed in a passwordless login link.
+const TokenTypeMagicLink = "magic_link"
+
+const magicLinkTTL = 15 * time.Minute
+
+type MagicLinkService interface {
+	// Send emails a login link when the email belongs to a user and does
+	// nothing otherwise, callers must not tell the two apart.
+	Send(ctx context.Context, email, userAgent string) error
+	// Peek returns the user of a valid, unused link without burning it.
+	Peek(ctx context.Context, token, userAgent string) (*entity.User, error)
+	// Consume burns a link and returns its user, a link can only be used
+	// once. With user agent binding on, it must come from the browser that
+	// asked for the link.
+	Consume(ctx context.Context, token, userAgent string) (*entity.User, error)
+}
+
+type magicLinkClaims struct {
+	UserID string `json:"id"`
+	Type   string `json:"type"`
+	// Fingerprint is the SHA-256 of the user agent that asked for the link
+	Fingerprint string `json:"uaf,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type magicLinkService struct {
+	userService   UserService
+	signer        Signer
+	revocations   repository.RevocationStore
+	mailer        mail.Sender
+	loginURL      string
+	bindUserAgent bool
+	log           *log.Logger
+}
+
+// NewMagicLinkService keeps no link state server side: links carry a token
+// signed with signer, burned once used. They point to loginURL with the
+// token in the "token" query parameter.
+func NewMagicLinkService(us UserService, signer Signer, revocations repository.RevocationStore, mailer mail.Sender, loginURL string, bindUserAgent bool) *magicLinkService {
+	return &magicLinkService{
+		userService:   us,
+		signer:        signer,
+		revocations:   revocations,
+		mailer:        mailer,
+		loginURL:      loginURL,
+		bindUserAgent: bindUserAgent,
+		log:           log.Default(),
+	}
+}
+
+func (s *magicLinkService) Send(ctx context.Context, email, userAgent string) error {
+	user, err := s.userService.GetByEmail(ctx, email)
+	if err != nil {
+		if err.Error() == constants.ErrMsgUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	claims := magicLinkClaims{
+		UserID: user.ID,
+		Type:   TokenTypeMagicLink,
+	}
+	if s.bindUserAgent {
+		claims.Fingerprint = userAgentFingerprint(userAgent)
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(magicLinkTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+
+	token, err := s.signer.Sign(claims)
+	if err != nil {
+		return err
+	}
+
+	mail.SendInBackground(ctx, s.mailer, mail.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Follow this link within %d minutes to log in:\n\n%s?token=%s\n\nIf you didn't ask for it you can ignore this email.",
+			int(magicLinkTTL.Minutes()), s.loginURL, url.QueryEscape(token)),
+	})
+
+	return nil
+}
+
+func (s *magicLinkService) Peek(ctx context.Context, link, userAgent string) (*entity.User, error) {
+	claims, err := s.parse(ctx, link, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, claims.ID, "", "", claims.IssuedAt.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	user, err := s.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+func (s *magicLinkService) Consume(ctx context.Context, link, userAgent string) (*entity.User, error) {
+	claims, err := s.parse(ctx, link, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one of concurrent uses of the link gets through
+	consumed, err := s.revocations.ConsumeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	if !consumed {
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	user, err := s.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	// Opening the link proves the user reads that inbox
+	if !user.EmailVerified() {
+		if err := s.userService.MarkEmailVerified(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+// parse checks the signature of a link and, with binding on, the user agent
+// using it.
+func (s *magicLinkService) parse(ctx context.Context, link, userAgent string) (*magicLinkClaims, error) {
+	token, err := jwt.ParseWithClaims(link, &magicLinkClaims{}, s.signer.Keyfunc)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	claims, ok := token.Claims.(*magicLinkClaims)
+	if !ok || !token.Valid || claims.Type != TokenTypeMagicLink || claims.ID == "" || claims.UserID == "" {
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	// Links issued before binding was turned on carry no fingerprint
+	if s.bindUserAgent && claims.Fingerprint != "" &&
+		subtle.ConstantTimeCompare([]byte(claims.Fingerprint), []byte(userAgentFingerprint(userAgent))) != 1 {
+		s.log.Printf("SECURITY: login link of user %s used from another user agent", claims.UserID)
+		return nil, errors.New(constants.ErrMsgInvalidMagicLink)
+	}
+
+	return claims, nil
+}
+
+func userAgentFingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMagicLink(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := &entity.User{ID: "user-id", Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+
+	// requestLink asks for a link from userAgent and returns its token.
+	requestLink := func(t *testing.T, bind bool, userAgent string) (service.MagicLinkService, string) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		sent := make(outbox, 1)
+		links := service.NewMagicLinkService(newUserService(userRepo), service.NewHMACSigner("", "refresh"),
+			repository.NewMemoryRevocationStore(), sent, "http://app/login/link", bind)
+
+		require.NoError(t, links.Send(ctx, user.Email, userAgent))
+
+		var msg mail.Message
+		select {
+		case msg = <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("no email sent")
+		}
+
+		link := regexp.MustCompile(`http://app/login/link\?token=(\S+)`).FindStringSubmatch(msg.Body)
+		require.Len(t, link, 2)
+		token, err := url.QueryUnescape(link[1])
+		require.NoError(t, err)
+
+		return links, token
+	}
+
+	t.Run("A link logs in once", func(t *testing.T) {
+		links, token := requestLink(t, false, "browser")
+
+		got, err := links.Consume(ctx, token, "another browser")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		_, err = links.Consume(ctx, token, "another browser")
+		assert.EqualError(t, err, constants.ErrMsgInvalidMagicLink)
+	})
+
+	t.Run("Peeking doesn't burn a link", func(t *testing.T) {
+		links, token := requestLink(t, false, "browser")
+
+		got, err := links.Peek(ctx, token, "browser")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		_, err = links.Consume(ctx, token, "browser")
+		require.NoError(t, err)
+
+		_, err = links.Peek(ctx, token, "browser")
+		assert.EqualError(t, err, constants.ErrMsgInvalidMagicLink)
+	})
+
+	t.Run("Concurrent uses of a link log in once", func(t *testing.T) {
+		links, token := requestLink(t, false, "browser")
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		logins := 0
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := links.Consume(ctx, token, "browser"); err == nil {
+					mu.Lock()
+					logins++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, logins)
+	})
+
+	t.Run("A bound link only works in the browser that asked for it", func(t *testing.T) {
+		links, token := requestLink(t, true, "browser")
+
+		_, err := links.Consume(ctx, token, "another browser")
+		assert.EqualError(t, err, constants.ErrMsgInvalidMagicLink)
+
+		got, err := links.Consume(ctx, token, "browser")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+	})
+
+	t.Run("Other tokens are not links", func(t *testing.T) {
+		links, _ := requestLink(t, false, "browser")
+
+		mfaToken, err := newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)).MFAToken(user)
+		require.NoError(t, err)
+
+		_, err = links.Consume(ctx, mfaToken, "browser")
+		assert.EqualError(t, err, constants.ErrMsgInvalidMagicLink)
+	})
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/totp"
+	"github.com/skip2/go-qrcode"
+)
+
+const recoveryCodesCount = 10
+
+type MFAService interface {
+	Enabled(ctx context.Context, userID string) (bool, error)
+	EnrollTOTP(ctx context.Context, user *entity.User) (*dto.TOTPEnrollmentDTO, error)
+	// ConfirmTOTP enables two-factor with a first code and returns the
+	// plaintext recovery codes, they are never shown again.
+	ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error)
+	// Verify accepts a TOTP code or an unused recovery code.
+	Verify(ctx context.Context, userID, code string) error
+	Disable(ctx context.Context, userID, password string) error
+}
+
+type mfaService struct {
+	repo        repository.MFARepository
+	userService UserService
+	issuer      string
+}
+
+func NewMFAService(r repository.MFARepository, us UserService, issuer string) *mfaService {
+	return &mfaService{
+		repo:        r,
+		userService: us,
+		issuer:      issuer,
+	}
+}
+
+func (s *mfaService) Enabled(ctx context.Context, userID string) (bool, error) {
+	enrollment, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		if err.Error() == constants.ErrMsgMFANotEnrolled {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return enrollment.Enabled(), nil
+}
+
+func (s *mfaService) EnrollTOTP(ctx context.Context, user *entity.User) (*dto.TOTPEnrollmentDTO, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveTOTP(ctx, &entity.TOTP{UserID: user.ID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.issuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TOTPEnrollmentDTO{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRPayload:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+func (s *mfaService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	enrollment, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrollment.Enabled() {
+		return nil, errors.New(constants.ErrMsgMFAAlreadyEnabled)
+	}
+
+	step, ok := totp.Validate(enrollment.Secret, code, time.Now(), 1)
+	if !ok {
+		return nil, errors.New(constants.ErrMsgInvalidMFACode)
+	}
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.repo.ConfirmTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, userID, code string) error {
+	enrollment, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !enrollment.Enabled() {
+		return errors.New(constants.ErrMsgMFANotEnrolled)
+	}
+
+	if step, ok := totp.Validate(enrollment.Secret, code, time.Now(), 1); ok {
+		// A code can't be replayed, not even within its own time step
+		used, err := s.repo.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+		return errors.New(constants.ErrMsgInvalidMFACode)
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return errors.New(constants.ErrMsgInvalidMFACode)
+	}
+
+	return nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID, password string) error {
+	if err := s.userService.VerifyPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteTOTP(ctx, userID)
+}
+
+// newRecoveryCode returns a code like "k3j9d-2mf8q", 50 bits of entropy.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode ignores the formatting the user may or may not type.
+// Codes are random enough for a plain SHA-256.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetTOTP(ctx context.Context, userID string) (*entity.TOTP, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.TOTP), args.Error(1)
+}
+
+func (m *MockMFARepository) SaveTOTP(ctx context.Context, totp *entity.TOTP) error {
+	args := m.Called(ctx, totp)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ConfirmTOTP(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, step, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) DeleteTOTP(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	ctx := context.Background()
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	repo := new(MockMFARepository)
+	mfaService := service.NewMFAService(repo, newUserService(new(MockUserRepository)), "Golerplate")
+
+	repo.On("GetTOTP", mock.Anything, "user-id").Return(&entity.TOTP{UserID: "user-id", Secret: secret}, nil)
+
+	var hashes []string
+	repo.On("ConfirmTOTP", mock.Anything, "user-id", mock.AnythingOfType("int64"), mock.AnythingOfType("[]string")).
+		Run(func(args mock.Arguments) {
+			hashes = args.Get(3).([]string)
+		}).Return(nil)
+
+	_, err = mfaService.ConfirmTOTP(ctx, "user-id", "000000")
+	assert.EqualError(t, err, constants.ErrMsgInvalidMFACode)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	codes, err := mfaService.ConfirmTOTP(ctx, "user-id", code)
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Len(t, hashes, 10)
+	assert.NotContains(t, hashes, codes[0])
+}
+
+func TestMFAVerify(t *testing.T) {
+	ctx := context.Background()
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	confirmedAt := time.Now()
+	enrollment := &entity.TOTP{UserID: "user-id", Secret: secret, ConfirmedAt: &confirmedAt}
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+
+	t.Run("Valid code", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		mfaService := service.NewMFAService(repo, nil, "Golerplate")
+
+		repo.On("GetTOTP", mock.Anything, "user-id").Return(enrollment, nil)
+		repo.On("UseStep", mock.Anything, "user-id", step).Return(true, nil)
+
+		assert.NoError(t, mfaService.Verify(ctx, "user-id", code))
+	})
+
+	t.Run("Replayed code", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		mfaService := service.NewMFAService(repo, nil, "Golerplate")
+
+		repo.On("GetTOTP", mock.Anything, "user-id").Return(enrollment, nil)
+		repo.On("UseStep", mock.Anything, "user-id", step).Return(false, nil)
+
+		assert.EqualError(t, mfaService.Verify(ctx, "user-id", code), constants.ErrMsgInvalidMFACode)
+	})
+
+	t.Run("Recovery code ignores formatting", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		mfaService := service.NewMFAService(repo, nil, "Golerplate")
+
+		var hashes []string
+		repo.On("GetTOTP", mock.Anything, "user-id").Return(enrollment, nil)
+		repo.On("UseRecoveryCode", mock.Anything, "user-id", mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) {
+				hashes = append(hashes, args.String(2))
+			}).Return(true, nil)
+
+		assert.NoError(t, mfaService.Verify(ctx, "user-id", "abcde-fghij"))
+		assert.NoError(t, mfaService.Verify(ctx, "user-id", "ABCDEFGHIJ"))
+		assert.Equal(t, hashes[0], hashes[1])
+	})
+
+	t.Run("Not enabled", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		mfaService := service.NewMFAService(repo, nil, "Golerplate")
+
+		repo.On("GetTOTP", mock.Anything, "user-id").Return(&entity.TOTP{UserID: "user-id", Secret: secret}, nil)
+
+		assert.EqualError(t, mfaService.Verify(ctx, "user-id", code), constants.ErrMsgMFANotEnrolled)
+	})
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// OAuth grant types served by the token endpoint.
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+const authorizationCodeTTL = 5 * time.Minute
+
+type OAuthService interface {
+	// ValidateAuthorize checks an authorization request before the user is
+	// asked for consent. ErrMsgInvalidRedirectURI must be answered directly,
+	// other errors are sent back to the redirect URI.
+	ValidateAuthorize(ctx context.Context, req dto.AuthorizeRequestDTO) (*entity.OAuthClient, error)
+	// Authorize answers an authorization request the user, logged in with
+	// claims, consented to or denied, with the URI the browser must be sent
+	// to.
+	Authorize(ctx context.Context, claims *Claims, req dto.AuthorizeRequestDTO) (string, error)
+	// Token serves the token endpoint for an authenticated client.
+	Token(ctx context.Context, client *entity.OAuthClient, req dto.TokenRequestDTO, meta SessionMeta) (*dto.OAuthTokenResponseDTO, error)
+}
+
+type oauthService struct {
+	clients     repository.OAuthClientRepository
+	codes       repository.OAuthAuthorizationCodeRepository
+	users       UserService
+	authService AuthService
+	oidc        OIDCService
+	accessTTL   time.Duration
+}
+
+func NewOAuthService(clients repository.OAuthClientRepository, codes repository.OAuthAuthorizationCodeRepository, us UserService, as AuthService, oidc OIDCService, accessTTL time.Duration) *oauthService {
+	return &oauthService{
+		clients:     clients,
+		codes:       codes,
+		users:       us,
+		authService: as,
+		oidc:        oidc,
+		accessTTL:   accessTTL,
+	}
+}
+
+func (s *oauthService) ValidateAuthorize(ctx context.Context, req dto.AuthorizeRequestDTO) (*entity.OAuthClient, error) {
+	if uuid.Validate(req.ClientID) != nil {
+		return nil, errors.New(constants.ErrMsgInvalidRedirectURI)
+	}
+
+	client, err := s.clients.GetByID(ctx, req.ClientID)
+	if err != nil {
+		if err.Error() == constants.ErrMsgOAuthClientNotFound {
+			return nil, errors.New(constants.ErrMsgInvalidRedirectURI)
+		}
+		return nil, err
+	}
+
+	// Past this point the redirect URI can be trusted with the errors
+	if client.RevokedAt != nil || !client.AllowsRedirect(req.RedirectURI) {
+		return nil, errors.New(constants.ErrMsgInvalidRedirectURI)
+	}
+
+	if req.ResponseType != "code" {
+		return client, errors.New(constants.ErrMsgUnsupportedResponseType)
+	}
+
+	// PKCE is required from every client, plain challenges are refused
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) != base64.RawURLEncoding.EncodedLen(sha256.Size) {
+		return client, errors.New(constants.ErrMsgPKCERequired)
+	}
+
+	if !client.AllowsScope(req.Scope) || (hasScope(req.Scope, ScopeOpenID) && !s.oidc.Enabled()) {
+		return client, errors.New(constants.ErrMsgInvalidScope)
+	}
+
+	return client, nil
+}
+
+func (s *oauthService) Authorize(ctx context.Context, claims *Claims, req dto.AuthorizeRequestDTO) (string, error) {
+	client, err := s.ValidateAuthorize(ctx, req)
+	if err != nil {
+		if client == nil {
+			return "", err
+		}
+		return AuthorizeRedirect(req.RedirectURI, req.State, url.Values{"error": {OAuthErrorCode(err)}, "error_description": {err.Error()}}), nil
+	}
+
+	if req.Denied {
+		return AuthorizeRedirect(req.RedirectURI, req.State, url.Values{"error": {"access_denied"}, "error_description": {constants.ErrMsgAccessDenied}}), nil
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	authorization := &entity.OAuthAuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID,
+		UserID:        claims.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		ExpiresAt:     time.Now().Add(authorizationCodeTTL),
+	}
+	// Tokens issued before logins were timed have no auth_time
+	if claims.AuthTime != nil {
+		authorization.AuthTime = &claims.AuthTime.Time
+	}
+
+	err = s.codes.Create(ctx, authorization)
+	if err != nil {
+		return "", err
+	}
+
+	return AuthorizeRedirect(req.RedirectURI, req.State, url.Values{"code": {code}}), nil
+}
+
+func (s *oauthService) Token(ctx context.Context, client *entity.OAuthClient, req dto.TokenRequestDTO, meta SessionMeta) (*dto.OAuthTokenResponseDTO, error) {
+	switch req.GrantType {
+	case GrantAuthorizationCode:
+		return s.exchangeCode(ctx, client, req, meta)
+	case GrantRefreshToken:
+		pair, err := s.authService.RefreshToken(ctx, req.RefreshToken, client.ID)
+		if err != nil {
+			// A reused token goes on as is, for the handler to record it
+			if err.Error() == constants.ErrMsgInvalidToken {
+				return nil, errors.New(constants.ErrMsgInvalidGrant)
+			}
+			return nil, err
+		}
+		// The scope stays what was granted, so it isn't repeated
+		return s.tokenResponse(pair.AccessToken, pair.RefreshToken, ""), nil
+	case GrantClientCredentials:
+		return s.clientCredentials(client, req)
+	default:
+		return nil, errors.New(constants.ErrMsgUnsupportedGrantType)
+	}
+}
+
+func (s *oauthService) exchangeCode(ctx context.Context, client *entity.OAuthClient, req dto.TokenRequestDTO, meta SessionMeta) (*dto.OAuthTokenResponseDTO, error) {
+	// The code is spent whatever comes next, a wrong verifier burns it
+	code, err := s.codes.Consume(ctx, hashToken(req.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI || !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, errors.New(constants.ErrMsgInvalidGrant)
+	}
+
+	user, err := s.users.GetByID(ctx, code.UserID)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidGrant)
+	}
+
+	// The session is listed under the client's name
+	meta.DeviceName = client.Name
+	pair, err := s.authService.GrantToken(ctx, user, meta, OAuthGrant{ClientID: client.ID, Scope: code.Scope})
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.tokenResponse(pair.AccessToken, pair.RefreshToken, code.Scope)
+	if hasScope(code.Scope, ScopeOpenID) {
+		response.IDToken, err = s.oidc.IDToken(user, client.ID, code.Scope, code.Nonce, code.AuthTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+func (s *oauthService) clientCredentials(client *entity.OAuthClient, req dto.TokenRequestDTO) (*dto.OAuthTokenResponseDTO, error) {
+	// A public client has no secret, anybody could act as it
+	if client.Public {
+		return nil, errors.New(constants.ErrMsgUnauthorizedClient)
+	}
+
+	if !client.AllowsScope(req.Scope) {
+		return nil, errors.New(constants.ErrMsgInvalidScope)
+	}
+
+	accessToken, err := s.authService.ClientToken(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(accessToken, "", req.Scope), nil
+}
+
+func (s *oauthService) tokenResponse(accessToken, refreshToken, scope string) *dto.OAuthTokenResponseDTO {
+	return &dto.OAuthTokenResponseDTO{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}
+}
+
+// verifyCodeChallenge checks a PKCE verifier against its S256 challenge.
+func verifyCodeChallenge(verifier, challenge string) bool {
+	// RFC 7636 verifiers are 43 to 128 characters long
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// AuthorizeRedirect adds params and state to the query of the client's
+// redirect URI.
+func AuthorizeRedirect(redirectURI, state string, params url.Values) string {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	target.RawQuery = query.Encode()
+
+	return target.String()
+}
+
+// oauthErrorCodes are the RFC 6749 error codes of the OAuth errors.
+var oauthErrorCodes = map[string]string{
+	constants.ErrMsgInvalidClient:           "invalid_client",
+	constants.ErrMsgInvalidRedirectURI:      "invalid_request",
+	constants.ErrMsgUnsupportedResponseType: "unsupported_response_type",
+	constants.ErrMsgPKCERequired:            "invalid_request",
+	constants.ErrMsgInvalidScope:            "invalid_scope",
+	constants.ErrMsgAccessDenied:            "access_denied",
+	constants.ErrMsgInvalidGrant:            "invalid_grant",
+	constants.ErrMsgTokenReused:             "invalid_grant",
+	constants.ErrMsgUnsupportedGrantType:    "unsupported_grant_type",
+	constants.ErrMsgUnauthorizedClient:      "unauthorized_client",
+	constants.ErrMsgEmailNotVerified:        "access_denied",
+	constants.ErrMsgAccountDisabled:         "invalid_grant",
+}
+
+// OAuthErrorCode is the RFC 6749 error code of err, server_error for errors
+// that aren't the client's doing.
+func OAuthErrorCode(err error) string {
+	if code, ok := oauthErrorCodes[err.Error()]; ok {
+		return code
+	}
+	return "server_error"
+}
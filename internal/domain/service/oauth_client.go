@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type OAuthClientService interface {
+	// Create returns the plaintext secret once, only its hash is stored.
+	Create(ctx context.Context, req dto.CreateOAuthClientDTO) (*dto.OAuthClientCreatedDTO, error)
+	List(ctx context.Context) ([]*entity.OAuthClient, error)
+	Revoke(ctx context.Context, id string) error
+	// Authenticate checks the client credentials of a request, any mismatch
+	// is ErrMsgInvalidClient. Public clients are identified by their ID
+	// alone and must not send a secret.
+	Authenticate(ctx context.Context, clientID, secret string) (*entity.OAuthClient, error)
+}
+
+type oauthClientService struct {
+	repo repository.OAuthClientRepository
+}
+
+func NewOAuthClientService(r repository.OAuthClientRepository) *oauthClientService {
+	return &oauthClientService{
+		repo: r,
+	}
+}
+
+func (s *oauthClientService) Create(ctx context.Context, req dto.CreateOAuthClientDTO) (*dto.OAuthClientCreatedDTO, error) {
+	var violations util.ValidationErrors
+	if req.Public && len(req.RedirectURIs) == 0 {
+		violations = append(violations, util.ValidationError{Field: "redirect_uris", Message: "Public clients need at least one"})
+	}
+	for _, scope := range req.Scopes {
+		if strings.ContainsAny(scope, " \t\n") {
+			violations = append(violations, util.ValidationError{Field: "scopes", Message: "Scopes can't contain spaces"})
+			break
+		}
+	}
+	if len(violations) > 0 {
+		return nil, violations
+	}
+
+	client := &entity.OAuthClient{
+		ID:           uuid.NewString(),
+		Name:         req.Name,
+		Public:       req.Public,
+		RedirectURIs: append([]string{}, req.RedirectURIs...),
+		Scopes:       append([]string{}, req.Scopes...),
+	}
+
+	var secret string
+	if !req.Public {
+		var err error
+		secret, err = randomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := s.repo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+
+	return &dto.OAuthClientCreatedDTO{ClientSecret: secret, Client: client}, nil
+}
+
+func (s *oauthClientService) List(ctx context.Context) ([]*entity.OAuthClient, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *oauthClientService) Revoke(ctx context.Context, id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return errors.New(constants.ErrMsgOAuthClientNotFound)
+	}
+
+	return s.repo.Revoke(ctx, id)
+}
+
+func (s *oauthClientService) Authenticate(ctx context.Context, clientID, secret string) (*entity.OAuthClient, error) {
+	// A malformed ID would only make postgres complain
+	if uuid.Validate(clientID) != nil {
+		return nil, errors.New(constants.ErrMsgInvalidClient)
+	}
+
+	client, err := s.repo.GetByID(ctx, clientID)
+	if err != nil {
+		if err.Error() == constants.ErrMsgOAuthClientNotFound {
+			return nil, errors.New(constants.ErrMsgInvalidClient)
+		}
+		return nil, err
+	}
+
+	if client.RevokedAt != nil {
+		return nil, errors.New(constants.ErrMsgInvalidClient)
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, errors.New(constants.ErrMsgInvalidClient)
+		}
+		return client, nil
+	}
+
+	if secret == "" || subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, errors.New(constants.ErrMsgInvalidClient)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,272 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockOAuthClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuthClientRepository) Create(ctx context.Context, client *entity.OAuthClient) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepository) GetByID(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) List(ctx context.Context) ([]*entity.OAuthClient, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) Revoke(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// memoryAuthorizationCodes stores codes in a map, so they can be consumed
+// like the real table does.
+type memoryAuthorizationCodes map[string]*entity.OAuthAuthorizationCode
+
+func (m memoryAuthorizationCodes) Create(ctx context.Context, code *entity.OAuthAuthorizationCode) error {
+	m[code.CodeHash] = code
+	return nil
+}
+
+func (m memoryAuthorizationCodes) Consume(ctx context.Context, codeHash string) (*entity.OAuthAuthorizationCode, error) {
+	code, ok := m[codeHash]
+	if !ok || code.UsedAt != nil || time.Now().After(code.ExpiresAt) {
+		return nil, errors.New(constants.ErrMsgInvalidGrant)
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	return code, nil
+}
+
+func TestOAuthAuthorizationCode(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := &entity.User{ID: "user-id", FullName: "Test User", Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	login := &service.Claims{UserID: user.ID, Type: service.TokenTypeAccess, AuthTime: jwt.NewNumericDate(authTime)}
+	client := &entity.OAuthClient{
+		ID:           "11111111-1111-1111-1111-111111111111",
+		Name:         "Test app",
+		Public:       true,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"openid", "profile", "email"},
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	idSigner, err := service.NewSigner("", key)
+	require.NoError(t, err)
+
+	verifier := strings.Repeat("v", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	request := dto.AuthorizeRequestDTO{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		RedirectURI:         client.RedirectURIs[0],
+		Scope:               "profile",
+		State:               "xyz",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: "S256",
+	}
+
+	newOAuthService := func(t *testing.T, idSigner service.Signer) (service.OAuthService, *MockRefreshTokenRepository) {
+		clientRepo := new(MockOAuthClientRepository)
+		clientRepo.On("GetByID", mock.Anything, client.ID).Return(client, nil)
+
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		refreshRepo := new(MockRefreshTokenRepository)
+		refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).Return(nil)
+		sessionRepo := new(MockSessionRepository)
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil)
+		sessionRepo.On("Touch", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+
+		users := newUserService(userRepo)
+		authService := newAuthService("access", "refresh", refreshRepo, sessionRepo)
+		oidc := service.NewOIDCService(idSigner, "http://api", time.Minute, users)
+		return service.NewOAuthService(clientRepo, memoryAuthorizationCodes{}, users, authService, oidc, time.Minute), refreshRepo
+	}
+
+	// authorize returns the code the client is redirected with.
+	authorize := func(t *testing.T, oauth service.OAuthService, request dto.AuthorizeRequestDTO) string {
+		redirect, err := oauth.Authorize(ctx, login, request)
+		require.NoError(t, err)
+
+		target, err := url.Parse(redirect)
+		require.NoError(t, err)
+		assert.Equal(t, "xyz", target.Query().Get("state"))
+		require.NotEmpty(t, target.Query().Get("code"))
+
+		return target.Query().Get("code")
+	}
+
+	exchange := func(oauth service.OAuthService, code, verifier string) (*dto.OAuthTokenResponseDTO, error) {
+		return oauth.Token(ctx, client, dto.TokenRequestDTO{
+			GrantType:    service.GrantAuthorizationCode,
+			Code:         code,
+			RedirectURI:  client.RedirectURIs[0],
+			CodeVerifier: verifier,
+		}, service.SessionMeta{})
+	}
+
+	t.Run("A code is exchanged once with its verifier", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, idSigner)
+		code := authorize(t, oauth, request)
+
+		tokens, err := exchange(oauth, code, verifier)
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		assert.Equal(t, "profile", tokens.Scope)
+
+		claims := &service.Claims{}
+		_, err = jwt.ParseWithClaims(tokens.AccessToken, claims, service.NewHMACSigner("", "access").Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, client.ID, claims.ClientID)
+		assert.Equal(t, "profile", claims.Scope)
+		assert.Empty(t, claims.Roles)
+		assert.Empty(t, tokens.IDToken)
+
+		_, err = exchange(oauth, code, verifier)
+		assert.EqualError(t, err, constants.ErrMsgInvalidGrant)
+	})
+
+	t.Run("The openid scope adds an ID token", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, idSigner)
+
+		oidcRequest := request
+		oidcRequest.Scope = "openid email"
+		oidcRequest.Nonce = "n-0S6_WzA2Mj"
+		tokens, err := exchange(oauth, authorize(t, oauth, oidcRequest), verifier)
+		require.NoError(t, err)
+		require.NotEmpty(t, tokens.IDToken)
+
+		claims := &service.IDTokenClaims{}
+		_, err = jwt.ParseWithClaims(tokens.IDToken, claims, idSigner.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, "http://api", claims.Issuer)
+		assert.Equal(t, user.ID, claims.Subject)
+		assert.True(t, claims.VerifyAudience(client.ID, true))
+		assert.Equal(t, "n-0S6_WzA2Mj", claims.Nonce)
+		require.NotNil(t, claims.AuthTime)
+		assert.Equal(t, authTime.Unix(), claims.AuthTime.Unix())
+		assert.Equal(t, user.Email, claims.Email)
+		assert.Empty(t, claims.Name, "profile wasn't granted")
+	})
+
+	t.Run("A wrong verifier burns the code", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, idSigner)
+		code := authorize(t, oauth, request)
+
+		_, err := exchange(oauth, code, strings.Repeat("w", 43))
+		assert.EqualError(t, err, constants.ErrMsgInvalidGrant)
+
+		_, err = exchange(oauth, code, verifier)
+		assert.EqualError(t, err, constants.ErrMsgInvalidGrant)
+	})
+
+	t.Run("The openid scope is refused without a published key", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, service.NewHMACSigner("", "access"))
+
+		oidcRequest := request
+		oidcRequest.Scope = "openid"
+		_, err := oauth.ValidateAuthorize(ctx, oidcRequest)
+		assert.EqualError(t, err, constants.ErrMsgInvalidScope)
+	})
+
+	t.Run("Requests without PKCE are sent back", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, idSigner)
+
+		plain := request
+		plain.CodeChallengeMethod = "plain"
+		redirect, err := oauth.Authorize(ctx, login, plain)
+		require.NoError(t, err)
+
+		target, err := url.Parse(redirect)
+		require.NoError(t, err)
+		assert.Equal(t, "invalid_request", target.Query().Get("error"))
+		assert.Empty(t, target.Query().Get("code"))
+	})
+
+	t.Run("Unregistered redirect URIs are refused", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, idSigner)
+
+		foreign := request
+		foreign.RedirectURI = "https://evil.example.com/callback"
+		_, err := oauth.Authorize(ctx, login, foreign)
+		assert.EqualError(t, err, constants.ErrMsgInvalidRedirectURI)
+	})
+
+	t.Run("Refresh tokens stay with their client", func(t *testing.T) {
+		oauth, refreshRepo := newOAuthService(t, idSigner)
+		tokens, err := exchange(oauth, authorize(t, oauth, request), verifier)
+		require.NoError(t, err)
+
+		other := &entity.OAuthClient{ID: "22222222-2222-2222-2222-222222222222"}
+		_, err = oauth.Token(ctx, other, dto.TokenRequestDTO{GrantType: service.GrantRefreshToken, RefreshToken: tokens.RefreshToken}, service.SessionMeta{})
+		assert.EqualError(t, err, constants.ErrMsgInvalidGrant)
+		refreshRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+
+		refreshRepo.On("MarkUsed", mock.Anything, mock.AnythingOfType("string")).Return(true, nil).Once()
+		rotated, err := oauth.Token(ctx, client, dto.TokenRequestDTO{GrantType: service.GrantRefreshToken, RefreshToken: tokens.RefreshToken}, service.SessionMeta{})
+		require.NoError(t, err)
+		assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+	})
+
+	t.Run("Public clients can't use client credentials", func(t *testing.T) {
+		oauth, _ := newOAuthService(t, idSigner)
+
+		_, err := oauth.Token(ctx, client, dto.TokenRequestDTO{GrantType: service.GrantClientCredentials}, service.SessionMeta{})
+		assert.EqualError(t, err, constants.ErrMsgUnauthorizedClient)
+
+		confidential := *client
+		confidential.Public = false
+		tokens, err := oauth.Token(ctx, &confidential, dto.TokenRequestDTO{GrantType: service.GrantClientCredentials, Scope: "email"}, service.SessionMeta{})
+		require.NoError(t, err)
+		assert.Empty(t, tokens.RefreshToken)
+
+		claims := &service.Claims{}
+		_, err = jwt.ParseWithClaims(tokens.AccessToken, claims, service.NewHMACSigner("", "access").Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, service.TokenTypeClient, claims.Type)
+		assert.Equal(t, client.ID, claims.Subject)
+		assert.Empty(t, claims.UserID)
+
+		_, err = oauth.Token(ctx, &confidential, dto.TokenRequestDTO{GrantType: service.GrantClientCredentials, Scope: "admin"}, service.SessionMeta{})
+		assert.EqualError(t, err, constants.ErrMsgInvalidScope)
+	})
+}
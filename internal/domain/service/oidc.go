@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// OpenID Connect scopes, profile and email open up the claims of the user.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// IDTokenClaims are the claims of an OpenID Connect ID token. Issuer,
+// Subject and Audience come from the registered claims.
+type IDTokenClaims struct {
+	Nonce         string           `json:"nonce,omitempty"`
+	AuthTime      *jwt.NumericDate `json:"auth_time,omitempty"`
+	Name          string           `json:"name,omitempty"`
+	Email         string           `json:"email,omitempty"`
+	EmailVerified *bool            `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type OIDCService interface {
+	// Enabled tells whether the signer publishes its keys. ID tokens are
+	// only issued when clients can verify them, never under an HMAC secret.
+	Enabled() bool
+	// Discovery is the provider metadata stock OIDC clients configure
+	// themselves from.
+	Discovery() *dto.OIDCDiscoveryDTO
+	// IDToken is issued with the tokens of an authorization code whose scope
+	// holds openid. authTime is left out when unknown. It is
+	// ErrMsgOpenIDUnavailable when not Enabled.
+	IDToken(user *entity.User, clientID, scope, nonce string, authTime *time.Time) (string, error)
+	// UserInfo answers for an access token granted the openid scope, any
+	// other is ErrMsgOpenIDScopeRequired.
+	UserInfo(ctx context.Context, claims *Claims) (*dto.UserInfoDTO, error)
+}
+
+type oidcService struct {
+	signer Signer
+	issuer string
+	ttl    time.Duration
+	users  UserService
+}
+
+// NewOIDCService signs ID tokens with signer, the access token one whose
+// keys are published on the JWKS endpoint. issuer is the public URL of the
+// API, without the /api prefix.
+func NewOIDCService(signer Signer, issuer string, ttl time.Duration, us UserService) *oidcService {
+	return &oidcService{
+		signer: signer,
+		issuer: strings.TrimSuffix(issuer, "/"),
+		ttl:    ttl,
+		users:  us,
+	}
+}
+
+func (s *oidcService) Enabled() bool {
+	return len(s.signer.JWKS().Keys) > 0
+}
+
+func (s *oidcService) Discovery() *dto.OIDCDiscoveryDTO {
+	algs := []string{}
+	for _, key := range s.signer.JWKS().Keys {
+		if key.Alg != "" && !slices.Contains(algs, key.Alg) {
+			algs = append(algs, key.Alg)
+		}
+	}
+
+	return &dto.OIDCDiscoveryDTO{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/api/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/api/oauth/token",
+		UserInfoEndpoint:                  s.issuer + "/api/oauth/userinfo",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             s.issuer + "/api/oauth/introspect",
+		ScopesSupported:                   []string{ScopeOpenID, ScopeProfile, ScopeEmail},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "name", "email", "email_verified"},
+	}
+}
+
+func (s *oidcService) IDToken(user *entity.User, clientID, scope, nonce string, authTime *time.Time) (string, error) {
+	// HMAC keys aren't published, clients couldn't verify the token
+	if !s.Enabled() {
+		return "", errors.New(constants.ErrMsgOpenIDUnavailable)
+	}
+
+	now := time.Now()
+	info := userInfo(user, scope)
+
+	claims := IDTokenClaims{
+		Nonce:         nonce,
+		Name:          info.Name,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	if authTime != nil {
+		claims.AuthTime = jwt.NewNumericDate(*authTime)
+	}
+
+	return s.signer.Sign(claims)
+}
+
+func (s *oidcService) UserInfo(ctx context.Context, claims *Claims) (*dto.UserInfoDTO, error) {
+	if claims.Type != TokenTypeAccess || !hasScope(claims.Scope, ScopeOpenID) {
+		return nil, errors.New(constants.ErrMsgOpenIDScopeRequired)
+	}
+
+	user, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := userInfo(user, claims.Scope)
+	return &info, nil
+}
+
+// userInfo holds the claims of user that scope lets the client see.
+func userInfo(user *entity.User, scope string) dto.UserInfoDTO {
+	info := dto.UserInfoDTO{Sub: user.ID}
+
+	if hasScope(scope, ScopeProfile) {
+		info.Name = user.FullName
+	}
+
+	if hasScope(scope, ScopeEmail) {
+		verified := user.EmailVerified()
+		info.Email = user.Email
+		info.EmailVerified = &verified
+	}
+
+	return info
+}
+
+// hasScope reports whether the space separated scope holds want.
+func hasScope(scope, want string) bool {
+	return slices.Contains(strings.Fields(scope), want)
+}
@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserInfo(t *testing.T) {
+	ctx := context.Background()
+	user := &entity.User{ID: "user-id", FullName: "Test User", Email: "test@example.com"}
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	oidc := service.NewOIDCService(service.NewHMACSigner("", "access"), "http://api/", time.Minute, newUserService(userRepo))
+
+	grant := func(scope string) *service.Claims {
+		return &service.Claims{UserID: user.ID, Type: service.TokenTypeAccess, ClientID: "client-id", Scope: scope}
+	}
+
+	t.Run("Claims follow the granted scopes", func(t *testing.T) {
+		info, err := oidc.UserInfo(ctx, grant("openid"))
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, info.Sub)
+		assert.Empty(t, info.Name)
+		assert.Empty(t, info.Email)
+		assert.Nil(t, info.EmailVerified)
+
+		info, err = oidc.UserInfo(ctx, grant("openid profile email"))
+		require.NoError(t, err)
+		assert.Equal(t, user.FullName, info.Name)
+		assert.Equal(t, user.Email, info.Email)
+		require.NotNil(t, info.EmailVerified)
+		assert.False(t, *info.EmailVerified)
+	})
+
+	t.Run("Tokens without openid are refused", func(t *testing.T) {
+		_, err := oidc.UserInfo(ctx, grant("profile email"))
+		assert.EqualError(t, err, constants.ErrMsgOpenIDScopeRequired)
+
+		// First-party logins carry no scope at all
+		_, err = oidc.UserInfo(ctx, &service.Claims{UserID: user.ID, Type: service.TokenTypeAccess})
+		assert.EqualError(t, err, constants.ErrMsgOpenIDScopeRequired)
+	})
+}
+
+func TestDiscovery(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := service.NewSigner("", key)
+	require.NoError(t, err)
+
+	discovery := service.NewOIDCService(signer, "https://auth.example.com/", time.Minute, nil).Discovery()
+	assert.Equal(t, "https://auth.example.com", discovery.Issuer)
+	assert.Equal(t, "https://auth.example.com/api/oauth/token", discovery.TokenEndpoint)
+	assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", discovery.JWKSURI)
+	assert.Equal(t, []string{"ES256"}, discovery.IDTokenSigningAlgValuesSupported)
+	assert.Equal(t, []string{"S256"}, discovery.CodeChallengeMethodsSupported)
+}
+
+func TestIDTokenNeedsPublishedKey(t *testing.T) {
+	user := &entity.User{ID: "user-id"}
+
+	oidc := service.NewOIDCService(service.NewHMACSigner("", "access"), "http://api", time.Minute, nil)
+	assert.False(t, oidc.Enabled())
+
+	_, err := oidc.IDToken(user, "client-id", "openid", "", nil)
+	assert.EqualError(t, err, constants.ErrMsgOpenIDUnavailable)
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// TokenTypePasskeyCeremony carries the WebAuthn session data between the
+// begin and finish steps of a ceremony.
+const TokenTypePasskeyCeremony = "passkey_ceremony"
+
+const passkeyCeremonyTTL = 5 * time.Minute
+
+type PasskeyService interface {
+	BeginRegistration(ctx context.Context, user *entity.User) (*dto.PasskeyRegistrationDTO, error)
+	FinishRegistration(ctx context.Context, user *entity.User, ceremony, name string, credential []byte) (*entity.WebAuthnCredential, error)
+	// BeginLogin starts a discoverable login, the authenticator picks the
+	// account so no email is needed.
+	BeginLogin(ctx context.Context) (*dto.PasskeyLoginDTO, error)
+	FinishLogin(ctx context.Context, ceremony string, credential []byte) (*entity.User, error)
+	List(ctx context.Context, userID string) ([]*entity.WebAuthnCredential, error)
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type ceremonyClaims struct {
+	Type    string               `json:"type"`
+	Session webauthn.SessionData `json:"session"`
+	jwt.RegisteredClaims
+}
+
+type passkeyService struct {
+	webAuthn    *webauthn.WebAuthn
+	repo        repository.WebAuthnRepository
+	userService UserService
+	signer      Signer
+	revocations repository.RevocationStore
+	log         *log.Logger
+}
+
+// NewPasskeyService keeps no ceremony state server side: the session data
+// travels in a token signed with signer, burned once the ceremony finishes.
+func NewPasskeyService(wa *webauthn.WebAuthn, r repository.WebAuthnRepository, us UserService, signer Signer, revocations repository.RevocationStore) *passkeyService {
+	return &passkeyService{
+		webAuthn:    wa,
+		repo:        r,
+		userService: us,
+		signer:      signer,
+		revocations: revocations,
+		log:         log.Default(),
+	}
+}
+
+func (s *passkeyService) BeginRegistration(ctx context.Context, user *entity.User) (*dto.PasskeyRegistrationDTO, error) {
+	owner, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(owner.credentials))
+	for _, credential := range owner.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, session, err := s.webAuthn.BeginRegistration(owner,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.ceremonyToken(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PasskeyRegistrationDTO{Ceremony: ceremony, Options: options}, nil
+}
+
+func (s *passkeyService) FinishRegistration(ctx context.Context, user *entity.User, ceremony, name string, credential []byte) (*entity.WebAuthnCredential, error) {
+	session, err := s.consumeCeremony(ctx, ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(credential)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidPasskey)
+	}
+
+	owner, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.webAuthn.CreateCredential(owner, *session, parsed)
+	if err != nil {
+		s.log.Printf("PASSKEY SERVICE: registration rejected for user %s: %s", user.ID, protocolError(err))
+		return nil, errors.New(constants.ErrMsgInvalidPasskey)
+	}
+
+	transports := make([]string, len(created.Transport))
+	for i, transport := range created.Transport {
+		transports[i] = string(transport)
+	}
+
+	passkey := &entity.WebAuthnCredential{
+		ID:              uuid.NewString(),
+		UserID:          user.ID,
+		CredentialID:    created.ID,
+		PublicKey:       created.PublicKey,
+		AttestationType: created.AttestationType,
+		AAGUID:          created.Authenticator.AAGUID,
+		SignCount:       created.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  created.Flags.BackupEligible,
+		BackupState:     created.Flags.BackupState,
+		Name:            name,
+	}
+
+	if err := s.repo.Create(ctx, passkey); err != nil {
+		return nil, err
+	}
+
+	return passkey, nil
+}
+
+func (s *passkeyService) BeginLogin(ctx context.Context) (*dto.PasskeyLoginDTO, error) {
+	options, session, err := s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.ceremonyToken(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PasskeyLoginDTO{Ceremony: ceremony, Options: options}, nil
+}
+
+func (s *passkeyService) FinishLogin(ctx context.Context, ceremony string, credential []byte) (*entity.User, error) {
+	session, err := s.consumeCeremony(ctx, ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(credential)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidPasskey)
+	}
+
+	var owner *webAuthnUser
+	lookup := func(rawID, userHandle []byte) (webauthn.User, error) {
+		user, err := s.userService.GetByID(ctx, string(userHandle))
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err = s.loadUser(ctx, user)
+		return owner, err
+	}
+
+	validated, err := s.webAuthn.ValidateDiscoverableLogin(lookup, *session, parsed)
+	if err != nil {
+		s.log.Printf("PASSKEY SERVICE: assertion rejected: %s", protocolError(err))
+		return nil, errors.New(constants.ErrMsgInvalidPasskey)
+	}
+
+	if validated.Authenticator.CloneWarning {
+		s.log.Printf("SECURITY: passkey sign count went backwards for user %s, the authenticator may be cloned", owner.user.ID)
+		return nil, errors.New(constants.ErrMsgInvalidPasskey)
+	}
+
+	if err := s.repo.UpdateSignCount(ctx, validated.ID, validated.Authenticator.SignCount, validated.Flags.BackupState); err != nil {
+		return nil, err
+	}
+
+	return owner.user, nil
+}
+
+func (s *passkeyService) List(ctx context.Context, userID string) ([]*entity.WebAuthnCredential, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *passkeyService) Delete(ctx context.Context, userID, id string) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *passkeyService) ceremonyToken(session *webauthn.SessionData) (string, error) {
+	now := time.Now()
+	return s.signer.Sign(ceremonyClaims{
+		Type:    TokenTypePasskeyCeremony,
+		Session: *session,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(passkeyCeremonyTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	})
+}
+
+// consumeCeremony validates a ceremony token and revokes it, a challenge
+// is only ever answered once.
+func (s *passkeyService) consumeCeremony(ctx context.Context, ceremony string) (*webauthn.SessionData, error) {
+	token, err := jwt.ParseWithClaims(ceremony, &ceremonyClaims{}, s.signer.Keyfunc)
+	if err != nil {
+		return nil, errors.New(constants.ErrMsgInvalidPasskeySession)
+	}
+
+	claims, ok := token.Claims.(*ceremonyClaims)
+	if !ok || !token.Valid || claims.Type != TokenTypePasskeyCeremony || claims.ID == "" {
+		return nil, errors.New(constants.ErrMsgInvalidPasskeySession)
+	}
+
+	// Only one of concurrent uses of the token gets through
+	consumed, err := s.revocations.ConsumeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	if !consumed {
+		return nil, errors.New(constants.ErrMsgInvalidPasskeySession)
+	}
+
+	return &claims.Session, nil
+}
+
+func (s *passkeyService) loadUser(ctx context.Context, user *entity.User) (*webAuthnUser, error) {
+	passkeys, err := s.repo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := &webAuthnUser{user: user, credentials: make([]webauthn.Credential, len(passkeys))}
+	for i, passkey := range passkeys {
+		transports := make([]protocol.AuthenticatorTransport, len(passkey.Transports))
+		for j, transport := range passkey.Transports {
+			transports[j] = protocol.AuthenticatorTransport(transport)
+		}
+
+		owner.credentials[i] = webauthn.Credential{
+			ID:              passkey.CredentialID,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    passkey.AAGUID,
+				SignCount: passkey.SignCount,
+			},
+		}
+	}
+
+	return owner, nil
+}
+
+// webAuthnUser adapts a user and its passkeys to webauthn.User. The user
+// handle is the user ID, never the email, so it stays stable.
+type webAuthnUser struct {
+	user        *entity.User
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.user.ID)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.FullName
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// protocolError keeps the detail the WebAuthn library attaches to its
+// errors, which only belongs in the logs.
+func protocolError(err error) string {
+	var perr *protocol.Error
+	if errors.As(err, &perr) && perr.DevInfo != "" {
+		return perr.Details + ": " + perr.DevInfo
+	}
+	if perr != nil {
+		return perr.Details
+	}
+	return err.Error()
+}
@@ -0,0 +1,259 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:3000"
+)
+
+type MockWebAuthnRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebAuthnRepository) Create(ctx context.Context, credential *entity.WebAuthnCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnRepository) ListByUser(ctx context.Context, userID string) ([]*entity.WebAuthnCredential, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.WebAuthnCredential), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, backupState bool) error {
+	args := m.Called(ctx, credentialID, signCount, backupState)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnRepository) Delete(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+// softAuthenticator plays the browser and a platform authenticator: it
+// answers ceremonies with a P-256 key and "none" attestation.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (a *softAuthenticator) create(t *testing.T, options *protocol.CredentialCreation) []byte {
+	a.userHandle = options.Response.User.ID.(protocol.URLEncodedBase64)
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	// Attested credential data: AAGUID, credential ID length, ID and key
+	attested := make([]byte, 16, 18+len(a.credentialID)+len(publicKey))
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	authData := append(a.authenticatorData(0x40), attested...)
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	require.NoError(t, err)
+
+	return marshalCredential(t, a.credentialID, map[string]any{
+		"clientDataJSON":    encode(clientData(t, "webauthn.create", options.Response.Challenge)),
+		"attestationObject": encode(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+func (a *softAuthenticator) get(t *testing.T, options *protocol.CredentialAssertion) []byte {
+	a.signCount++
+	authData := a.authenticatorData(0)
+	client := clientData(t, "webauthn.get", options.Response.Challenge)
+
+	clientHash := sha256.Sum256(client)
+	digest := sha256.Sum256(append(authData, clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	return marshalCredential(t, a.credentialID, map[string]any{
+		"clientDataJSON":    encode(client),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+// authenticatorData sets user present and verified on top of extra flags.
+func (a *softAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], 0x01|0x04|flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func clientData(t *testing.T, ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge.String(),
+		"origin":    testOrigin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func marshalCredential(t *testing.T, id []byte, response map[string]any) []byte {
+	credential, err := json.Marshal(map[string]any{
+		"id":       encode(id),
+		"rawId":    encode(id),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(t, err)
+	return credential
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newPasskeyService(t *testing.T, repo *MockWebAuthnRepository, user *entity.User) service.PasskeyService {
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          testRPID,
+		RPDisplayName: "Golerplate",
+		RPOrigins:     []string{testOrigin},
+	})
+	require.NoError(t, err)
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	return service.NewPasskeyService(wa, repo, newUserService(userRepo), service.NewHMACSigner("", "refresh-secret"), repository.NewMemoryRevocationStore())
+}
+
+func TestPasskeyCeremonies(t *testing.T) {
+	ctx := context.Background()
+	user := &entity.User{ID: uuid.NewString(), Email: "test@gmail.com", FullName: "Test"}
+
+	// register enrolls a new software authenticator and returns the stored passkey
+	register := func(t *testing.T, passkeyService service.PasskeyService, repo *MockWebAuthnRepository) (*softAuthenticator, *entity.WebAuthnCredential) {
+		authenticator := newSoftAuthenticator(t)
+		repo.On("ListByUser", mock.Anything, user.ID).Return([]*entity.WebAuthnCredential{}, nil).Twice()
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.WebAuthnCredential")).Return(nil).Once()
+
+		registration, err := passkeyService.BeginRegistration(ctx, user)
+		require.NoError(t, err)
+
+		passkey, err := passkeyService.FinishRegistration(ctx, user, registration.Ceremony, "Laptop", authenticator.create(t, registration.Options))
+		require.NoError(t, err)
+
+		return authenticator, passkey
+	}
+
+	t.Run("Registers and logs in with a passkey", func(t *testing.T) {
+		repo := new(MockWebAuthnRepository)
+		passkeyService := newPasskeyService(t, repo, user)
+
+		authenticator, passkey := register(t, passkeyService, repo)
+		assert.Equal(t, authenticator.credentialID, passkey.CredentialID)
+		assert.Equal(t, user.ID, passkey.UserID)
+		assert.Equal(t, "Laptop", passkey.Name)
+		assert.Equal(t, []string{"internal"}, passkey.Transports)
+
+		repo.On("ListByUser", mock.Anything, user.ID).Return([]*entity.WebAuthnCredential{passkey}, nil)
+		repo.On("UpdateSignCount", mock.Anything, authenticator.credentialID, uint32(1), false).Return(nil).Once()
+
+		login, err := passkeyService.BeginLogin(ctx)
+		require.NoError(t, err)
+
+		assertion := authenticator.get(t, login.Options)
+		loggedIn, err := passkeyService.FinishLogin(ctx, login.Ceremony, assertion)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, loggedIn.ID)
+
+		// The ceremony is single use, replaying the assertion fails
+		_, err = passkeyService.FinishLogin(ctx, login.Ceremony, assertion)
+		assert.EqualError(t, err, constants.ErrMsgInvalidPasskeySession)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an assertion signed by another key", func(t *testing.T) {
+		repo := new(MockWebAuthnRepository)
+		passkeyService := newPasskeyService(t, repo, user)
+
+		authenticator, passkey := register(t, passkeyService, repo)
+		repo.On("ListByUser", mock.Anything, user.ID).Return([]*entity.WebAuthnCredential{passkey}, nil)
+
+		login, err := passkeyService.BeginLogin(ctx)
+		require.NoError(t, err)
+
+		impostor := newSoftAuthenticator(t)
+		impostor.credentialID, impostor.userHandle = authenticator.credentialID, authenticator.userHandle
+
+		_, err = passkeyService.FinishLogin(ctx, login.Ceremony, impostor.get(t, login.Options))
+		assert.EqualError(t, err, constants.ErrMsgInvalidPasskey)
+		repo.AssertNotCalled(t, "UpdateSignCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects a sign count that went backwards", func(t *testing.T) {
+		repo := new(MockWebAuthnRepository)
+		passkeyService := newPasskeyService(t, repo, user)
+
+		authenticator, passkey := register(t, passkeyService, repo)
+		passkey.SignCount = 5
+		repo.On("ListByUser", mock.Anything, user.ID).Return([]*entity.WebAuthnCredential{passkey}, nil)
+
+		login, err := passkeyService.BeginLogin(ctx)
+		require.NoError(t, err)
+
+		_, err = passkeyService.FinishLogin(ctx, login.Ceremony, authenticator.get(t, login.Options))
+		assert.EqualError(t, err, constants.ErrMsgInvalidPasskey)
+		repo.AssertNotCalled(t, "UpdateSignCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
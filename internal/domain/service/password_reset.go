@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+const passwordResetTTL = time.Hour
+
+type PasswordResetService interface {
+	// Forgot emails a reset link when the email belongs to a user and does
+	// nothing otherwise, callers must not tell the two apart.
+	Forgot(ctx context.Context, email string) error
+	// Reset sets a new password with a token from Forgot and signs the user
+	// out of every session. It returns the ID of the user.
+	Reset(ctx context.Context, token, password string) (string, error)
+}
+
+type passwordResetService struct {
+	repo        repository.PasswordResetRepository
+	userService UserService
+	authService AuthService
+	mailer      mail.Sender
+	resetURL    string
+	log         *log.Logger
+}
+
+// NewPasswordResetService sends links to resetURL with the token in the
+// "token" query parameter.
+func NewPasswordResetService(r repository.PasswordResetRepository, us UserService, as AuthService, mailer mail.Sender, resetURL string) *passwordResetService {
+	return &passwordResetService{
+		repo:        r,
+		userService: us,
+		authService: as,
+		mailer:      mailer,
+		resetURL:    resetURL,
+		log:         log.Default(),
+	}
+}
+
+func (s *passwordResetService) Forgot(ctx context.Context, email string) error {
+	user, err := s.userService.GetByEmail(ctx, email)
+	if err != nil {
+		if err.Error() == constants.ErrMsgUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Create(ctx, &entity.PasswordResetToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sent in the background so the response time doesn't tell whether the
+	// account exists
+	mail.SendInBackground(ctx, s.mailer, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account. If it was you, follow this link within %d minutes:\n\n%s?token=%s\n\nOtherwise you can ignore this email.",
+			int(passwordResetTTL.Minutes()), s.resetURL, url.QueryEscape(token)),
+	})
+
+	return nil
+}
+
+func (s *passwordResetService) Reset(ctx context.Context, token, password string) (string, error) {
+	tokenHash := hashToken(token)
+
+	userID, err := s.repo.Find(ctx, tokenHash)
+	if err != nil {
+		return "", err
+	}
+
+	// A password the policy refuses must not burn the link
+	if err := s.userService.CheckPassword(ctx, userID, password); err != nil {
+		return userID, err
+	}
+
+	if _, err := s.repo.Consume(ctx, tokenHash); err != nil {
+		return userID, err
+	}
+
+	if err := s.userService.SetPassword(ctx, userID, password); err != nil {
+		return userID, err
+	}
+
+	// Any other link sent before is stale now
+	if err := s.repo.DeleteByUser(ctx, userID); err != nil {
+		return userID, err
+	}
+
+	s.log.Printf("SECURITY: password of user %s reset, signing out every session", userID)
+
+	return userID, s.authService.LogoutAll(ctx, userID)
+}
+
+// randomToken returns n random bytes, URL safe.
+func randomToken(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken is how emailed tokens are stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
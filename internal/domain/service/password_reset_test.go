@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/mail"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *entity.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) Find(ctx context.Context, tokenHash string) (string, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) Consume(ctx context.Context, tokenHash string) (string, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) DeleteByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// outbox keeps what would have been emailed.
+type outbox chan mail.Message
+
+func (o outbox) Send(ctx context.Context, msg mail.Message) error {
+	o <- msg
+	return nil
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	user := &entity.User{ID: "user-id", Email: "test@example.com"}
+
+	t.Run("Emails a single-use link and signs out every session", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+		userRepo.On("UpdatePassword", mock.Anything, user.ID, mock.MatchedBy(func(hash string) bool {
+			match, _, err := testHasher.Verify("new-password", hash)
+			return match && err == nil
+		})).Return(nil).Once()
+
+		refreshRepo := new(MockRefreshTokenRepository)
+		refreshRepo.On("RevokeByUser", mock.Anything, user.ID).Return(nil).Once()
+		sessionRepo := new(MockSessionRepository)
+		sessionRepo.On("RevokeByUser", mock.Anything, user.ID).Return(nil).Once()
+
+		var stored *entity.PasswordResetToken
+		repo := new(MockPasswordResetRepository)
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.PasswordResetToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.PasswordResetToken) }).
+			Return(nil)
+
+		sent := make(outbox, 1)
+		resets := service.NewPasswordResetService(repo, newUserService(userRepo),
+			newAuthService("access", "refresh", refreshRepo, sessionRepo), sent, "http://app/reset-password")
+
+		require.NoError(t, resets.Forgot(ctx, user.Email))
+
+		var msg mail.Message
+		select {
+		case msg = <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("no email sent")
+		}
+		assert.Equal(t, user.Email, msg.To)
+
+		link := regexp.MustCompile(`http://app/reset-password\?token=(\S+)`).FindStringSubmatch(msg.Body)
+		require.Len(t, link, 2)
+		token, err := url.QueryUnescape(link[1])
+		require.NoError(t, err)
+
+		// Only the hash is stored
+		sum := sha256.Sum256([]byte(token))
+		assert.Equal(t, hex.EncodeToString(sum[:]), stored.TokenHash)
+
+		repo.On("Find", mock.Anything, stored.TokenHash).Return(user.ID, nil).Once()
+		repo.On("Consume", mock.Anything, stored.TokenHash).Return(user.ID, nil).Once()
+		repo.On("DeleteByUser", mock.Anything, user.ID).Return(nil).Once()
+
+		userID, err := resets.Reset(ctx, token, "new-password")
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", userID)
+		userRepo.AssertExpectations(t)
+		refreshRepo.AssertExpectations(t)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown emails get no link and no error", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, "ghost@example.com").Return(nil, errors.New(constants.ErrMsgUserNotFound))
+		repo := new(MockPasswordResetRepository)
+
+		sent := make(outbox, 1)
+		resets := service.NewPasswordResetService(repo, newUserService(userRepo),
+			newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)), sent, "http://app/reset-password")
+
+		require.NoError(t, resets.Forgot(ctx, "ghost@example.com"))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		assert.Empty(t, sent)
+	})
+
+	t.Run("Rejects a used or expired token", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		repo := new(MockPasswordResetRepository)
+		repo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return("", errors.New(constants.ErrMsgInvalidResetToken))
+
+		resets := service.NewPasswordResetService(repo, newUserService(userRepo),
+			newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)), make(outbox, 1), "http://app/reset-password")
+
+		_, err := resets.Reset(ctx, "stale", "new-password")
+		assert.EqualError(t, err, constants.ErrMsgInvalidResetToken)
+		userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("A password refused by the policy keeps the token usable", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, "user-id").Return(&entity.User{ID: "user-id", FullName: "Test User", Email: "test@example.com"}, nil)
+		repo := new(MockPasswordResetRepository)
+		repo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return("user-id", nil)
+
+		resets := service.NewPasswordResetService(repo, service.NewUserService(userRepo, testHasher, &util.DefaultPasswordPolicy),
+			newAuthService("access", "refresh", new(MockRefreshTokenRepository), new(MockSessionRepository)), make(outbox, 1), "http://app/reset-password")
+
+		_, err := resets.Reset(ctx, "token", "test-user-1")
+		var violations util.ValidationErrors
+		require.ErrorAs(t, err, &violations)
+		repo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
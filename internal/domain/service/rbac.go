@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+type RBACService interface {
+	UserRoles(ctx context.Context, userID string) ([]string, error)
+	// HasPermission checks roles already at hand, such as the ones carried
+	// by an access token.
+	HasPermission(ctx context.Context, roles []string, permission string) (bool, error)
+	// Authorize checks the user's current roles and fails with
+	// ErrMsgForbidden, for services guarding their own operations.
+	Authorize(ctx context.Context, userID, permission string) error
+	ListRoles(ctx context.Context) ([]*entity.Role, error)
+	AssignRole(ctx context.Context, userID, role string) error
+	RemoveRole(ctx context.Context, userID, role string) error
+	// BootstrapAdmin makes the user an admin unless some user already is,
+	// it reports whether the role was granted.
+	BootstrapAdmin(ctx context.Context, userID string) (bool, error)
+}
+
+type rbacService struct {
+	repo repository.RBACRepository
+}
+
+func NewRBACService(r repository.RBACRepository) *rbacService {
+	return &rbacService{
+		repo: r,
+	}
+}
+
+func (s *rbacService) UserRoles(ctx context.Context, userID string) ([]string, error) {
+	return s.repo.UserRoles(ctx, userID)
+}
+
+func (s *rbacService) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	if len(roles) == 0 {
+		return false, nil
+	}
+
+	return s.repo.HasPermission(ctx, roles, permission)
+}
+
+func (s *rbacService) Authorize(ctx context.Context, userID, permission string) error {
+	roles, err := s.repo.UserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := s.HasPermission(ctx, roles, permission)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return errors.New(constants.ErrMsgForbidden)
+	}
+
+	return nil
+}
+
+func (s *rbacService) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+func (s *rbacService) AssignRole(ctx context.Context, userID, role string) error {
+	return s.repo.AssignRole(ctx, userID, role)
+}
+
+func (s *rbacService) RemoveRole(ctx context.Context, userID, role string) error {
+	return s.repo.RemoveRole(ctx, userID, role)
+}
+
+func (s *rbacService) BootstrapAdmin(ctx context.Context, userID string) (bool, error) {
+	admins, err := s.repo.CountMembers(ctx, entity.RoleAdmin)
+	if err != nil {
+		return false, err
+	}
+
+	if admins > 0 {
+		return false, nil
+	}
+
+	if err := s.repo.AssignRole(ctx, userID, entity.RoleAdmin); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRBACRepository struct {
+	mock.Mock
+}
+
+func (m *MockRBACRepository) UserRoles(ctx context.Context, userID string) ([]string, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRBACRepository) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	args := m.Called(ctx, roles, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRBACRepository) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Role), args.Error(1)
+}
+
+func (m *MockRBACRepository) CountMembers(ctx context.Context, role string) (int, error) {
+	args := m.Called(ctx, role)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRBACRepository) AssignRole(ctx context.Context, userID, role string) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) RemoveRole(ctx context.Context, userID, role string) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Allows a permission granted by a role", func(t *testing.T) {
+		repo := new(MockRBACRepository)
+		repo.On("UserRoles", mock.Anything, "user-id").Return([]string{entity.RoleAdmin}, nil)
+		repo.On("HasPermission", mock.Anything, []string{entity.RoleAdmin}, entity.PermUsersRead).Return(true, nil)
+
+		err := service.NewRBACService(repo).Authorize(ctx, "user-id", entity.PermUsersRead)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Forbids a user without roles", func(t *testing.T) {
+		repo := new(MockRBACRepository)
+		repo.On("UserRoles", mock.Anything, "user-id").Return([]string{}, nil)
+
+		err := service.NewRBACService(repo).Authorize(ctx, "user-id", entity.PermUsersRead)
+		assert.EqualError(t, err, constants.ErrMsgForbidden)
+		repo.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Grants admin while nobody holds it", func(t *testing.T) {
+		repo := new(MockRBACRepository)
+		repo.On("CountMembers", mock.Anything, entity.RoleAdmin).Return(0, nil)
+		repo.On("AssignRole", mock.Anything, "user-id", entity.RoleAdmin).Return(nil).Once()
+
+		granted, err := service.NewRBACService(repo).BootstrapAdmin(ctx, "user-id")
+		assert.NoError(t, err)
+		assert.True(t, granted)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Does nothing once there is an admin", func(t *testing.T) {
+		repo := new(MockRBACRepository)
+		repo.On("CountMembers", mock.Anything, entity.RoleAdmin).Return(1, nil)
+
+		granted, err := service.NewRBACService(repo).BootstrapAdmin(ctx, "user-id")
+		assert.NoError(t, err)
+		assert.False(t, granted)
+		repo.AssertNotCalled(t, "AssignRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAccessTokenCarriesRoles(t *testing.T) {
+	ctx := context.Background()
+
+	refreshRepo := new(MockRefreshTokenRepository)
+	refreshRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).Return(nil)
+	sessionRepo := new(MockSessionRepository)
+	sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil)
+	rbacRepo := new(MockRBACRepository)
+	rbacRepo.On("UserRoles", mock.Anything, "user-id").Return([]string{entity.RoleAdmin}, nil)
+
+	revocations := repository.NewMemoryRevocationStore()
+	accessSigner := service.NewHMACSigner("", "access")
+	authService := service.NewAuthService(
+		accessSigner,
+		service.NewHMACSigner("", "refresh"),
+		time.Minute,
+		time.Hour,
+		refreshRepo,
+		revocations,
+		service.NewSessionService(sessionRepo, refreshRepo, revocations, time.Minute, time.Hour),
+		service.NewRBACService(rbacRepo),
+		newUserService(new(MockUserRepository)),
+		service.EmailVerificationAllow,
+	)
+
+	pair, err := authService.GenerateToken(ctx, &entity.User{ID: "user-id"}, service.SessionMeta{})
+	require.NoError(t, err)
+
+	claims := &service.Claims{}
+	_, err = jwt.ParseWithClaims(pair.AccessToken, claims, accessSigner.Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{entity.RoleAdmin}, claims.Roles)
+
+	// The refresh token doesn't need them
+	refreshClaims := &service.Claims{}
+	_, _, err = jwt.NewParser().ParseUnverified(pair.RefreshToken, refreshClaims)
+	require.NoError(t, err)
+	assert.Empty(t, refreshClaims.Roles)
+}
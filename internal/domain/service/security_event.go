@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// securityEventPageSize is how many events a page holds when the query
+// doesn't say.
+const securityEventPageSize = 50
+
+type SecurityEventService interface {
+	// Record stores the event with the trace ID of ctx. The actor defaults
+	// to the principal of ctx, the admin when impersonating, and stays empty
+	// without one.
+	Record(ctx context.Context, event *entity.SecurityEvent) error
+	// List returns a page of events matching the query, newest first.
+	List(ctx context.Context, query dto.SecurityEventQueryDTO) (*dto.SecurityEventPageDTO, error)
+	// ListForUser returns a page of the events about the user. Where an
+	// admin acted on the account, the admin's IP and user agent are left out.
+	ListForUser(ctx context.Context, userID string, query dto.SecurityEventQueryDTO) (*dto.SecurityEventPageDTO, error)
+}
+
+type securityEventService struct {
+	repo repository.SecurityEventRepository
+}
+
+func NewSecurityEventService(r repository.SecurityEventRepository) *securityEventService {
+	return &securityEventService{
+		repo: r,
+	}
+}
+
+func (s *securityEventService) Record(ctx context.Context, event *entity.SecurityEvent) error {
+	event.ID = uuid.NewString()
+
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		event.TraceID = span.TraceID().String()
+	}
+
+	if principal, ok := auth.FromContext(ctx); ok && event.ActorID == "" {
+		event.ActorID = principal.UserID
+		if principal.Impersonated() {
+			event.ActorID = principal.ActorID
+		}
+	}
+
+	return s.repo.Create(ctx, event)
+}
+
+func (s *securityEventService) List(ctx context.Context, query dto.SecurityEventQueryDTO) (*dto.SecurityEventPageDTO, error) {
+	filter := entity.SecurityEventFilter{
+		UserID:  query.UserID,
+		ActorID: query.ActorID,
+		Type:    query.Type,
+		Outcome: query.Outcome,
+		IP:      query.IP,
+		Limit:   query.Limit,
+	}
+
+	// Nobody has an ID that isn't a uuid
+	if (filter.UserID != "" && uuid.Validate(filter.UserID) != nil) ||
+		(filter.ActorID != "" && uuid.Validate(filter.ActorID) != nil) {
+		return &dto.SecurityEventPageDTO{Events: []*entity.SecurityEvent{}}, nil
+	}
+
+	if !query.Since.IsZero() {
+		filter.Since = &query.Since
+	}
+	if !query.Until.IsZero() {
+		filter.Until = &query.Until
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = securityEventPageSize
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeSecurityEventCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.Before = cursor
+	}
+
+	// One more than asked tells whether there is a next page
+	filter.Limit++
+	events, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.SecurityEventPageDTO{Events: events}
+	if len(events) == filter.Limit {
+		page.Events = events[:len(events)-1]
+		last := page.Events[len(page.Events)-1]
+		page.NextCursor = encodeSecurityEventCursor(last)
+	}
+
+	return page, nil
+}
+
+func (s *securityEventService) ListForUser(ctx context.Context, userID string, query dto.SecurityEventQueryDTO) (*dto.SecurityEventPageDTO, error) {
+	query.UserID = userID
+	query.ActorID = ""
+
+	page, err := s.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range page.Events {
+		if event.ActorID != "" && event.ActorID != userID {
+			event.IP = ""
+			event.UserAgent = ""
+		}
+	}
+
+	return page, nil
+}
+
+// encodeSecurityEventCursor points past the event in the newest first order.
+func encodeSecurityEventCursor(event *entity.SecurityEvent) string {
+	position := event.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + event.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+func decodeSecurityEventCursor(cursor string) (*entity.SecurityEventCursor, error) {
+	invalid := errors.New(constants.ErrMsgInvalidCursor)
+
+	position, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	createdAt, id, found := strings.Cut(string(position), "|")
+	if !found || uuid.Validate(id) != nil {
+		return nil, invalid
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, invalid
+	}
+
+	return &entity.SecurityEventCursor{CreatedAt: at, ID: id}, nil
+}
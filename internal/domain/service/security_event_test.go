@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySecurityEvents keeps events in insertion order, a second apart.
+type memorySecurityEvents struct {
+	events []*entity.SecurityEvent
+}
+
+func (m *memorySecurityEvents) Create(ctx context.Context, event *entity.SecurityEvent) error {
+	event.CreatedAt = time.Date(2026, 1, 1, 0, 0, len(m.events), 0, time.UTC)
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memorySecurityEvents) List(ctx context.Context, filter entity.SecurityEventFilter) ([]*entity.SecurityEvent, error) {
+	events := []*entity.SecurityEvent{}
+	for i := len(m.events) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+		event := m.events[i]
+		if filter.UserID != "" && event.UserID != filter.UserID {
+			continue
+		}
+		if filter.Type != "" && event.Type != filter.Type {
+			continue
+		}
+		if filter.Before != nil && !event.CreatedAt.Before(filter.Before.CreatedAt) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func TestSecurityEvents(t *testing.T) {
+	ctx := context.Background()
+	adminID, userID := uuid.NewString(), uuid.NewString()
+
+	t.Run("The actor defaults to the principal, or stays empty", func(t *testing.T) {
+		events := service.NewSecurityEventService(&memorySecurityEvents{})
+
+		login := &entity.SecurityEvent{Type: entity.EventLogin, Outcome: entity.OutcomeSuccess, UserID: userID}
+		require.NoError(t, events.Record(ctx, login))
+		assert.NotEmpty(t, login.ID)
+		assert.Empty(t, login.ActorID)
+
+		adminCtx := auth.WithPrincipal(ctx, &auth.Principal{UserID: adminID})
+		disable := &entity.SecurityEvent{Type: entity.EventAccountDisable, Outcome: entity.OutcomeSuccess, UserID: userID}
+		require.NoError(t, events.Record(adminCtx, disable))
+		assert.Equal(t, adminID, disable.ActorID)
+
+		impersonatingCtx := auth.WithPrincipal(ctx, &auth.Principal{UserID: userID, ActorID: adminID})
+		logout := &entity.SecurityEvent{Type: entity.EventLogout, Outcome: entity.OutcomeSuccess, UserID: userID}
+		require.NoError(t, events.Record(impersonatingCtx, logout))
+		assert.Equal(t, adminID, logout.ActorID)
+	})
+
+	t.Run("Pages follow the cursor to the oldest event", func(t *testing.T) {
+		events := service.NewSecurityEventService(&memorySecurityEvents{})
+		for range 5 {
+			require.NoError(t, events.Record(ctx, &entity.SecurityEvent{Type: entity.EventRefresh, Outcome: entity.OutcomeSuccess, UserID: userID}))
+		}
+
+		var seen []*entity.SecurityEvent
+		query := dto.SecurityEventQueryDTO{UserID: userID, Limit: 2}
+		for range 3 {
+			page, err := events.List(ctx, query)
+			require.NoError(t, err)
+			seen = append(seen, page.Events...)
+			query.Cursor = page.NextCursor
+		}
+
+		assert.Empty(t, query.Cursor)
+		require.Len(t, seen, 5)
+		for i := 1; i < len(seen); i++ {
+			assert.True(t, seen[i].CreatedAt.Before(seen[i-1].CreatedAt))
+		}
+
+		_, err := events.List(ctx, dto.SecurityEventQueryDTO{Cursor: "not-a-cursor"})
+		assert.EqualError(t, err, constants.ErrMsgInvalidCursor)
+
+		page, err := events.List(ctx, dto.SecurityEventQueryDTO{UserID: "not-a-uuid"})
+		require.NoError(t, err)
+		assert.Empty(t, page.Events)
+	})
+
+	t.Run("Users don't see where admins acted from", func(t *testing.T) {
+		events := service.NewSecurityEventService(&memorySecurityEvents{})
+		adminCtx := auth.WithPrincipal(ctx, &auth.Principal{UserID: adminID})
+
+		require.NoError(t, events.Record(ctx, &entity.SecurityEvent{Type: entity.EventLogin, Outcome: entity.OutcomeSuccess, UserID: userID, IP: "10.0.0.1"}))
+		require.NoError(t, events.Record(adminCtx, &entity.SecurityEvent{Type: entity.EventAccountDisable, Outcome: entity.OutcomeSuccess, UserID: userID, IP: "10.0.0.2"}))
+		require.NoError(t, events.Record(ctx, &entity.SecurityEvent{Type: entity.EventLogin, Outcome: entity.OutcomeSuccess, UserID: adminID}))
+
+		page, err := events.ListForUser(ctx, userID, dto.SecurityEventQueryDTO{UserID: adminID})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 2)
+		assert.Equal(t, entity.EventAccountDisable, page.Events[0].Type)
+		assert.Empty(t, page.Events[0].IP)
+		assert.Equal(t, "10.0.0.1", page.Events[1].IP)
+	})
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+)
+
+// SessionMeta describes the device a login comes from.
+type SessionMeta struct {
+	UserAgent  string
+	IP         string
+	DeviceName string
+}
+
+type SessionService interface {
+	Start(ctx context.Context, userID string, meta SessionMeta) (*entity.Session, error)
+	Touch(ctx context.Context, sessionID string) error
+	List(ctx context.Context, userID string) ([]*entity.Session, error)
+	Rename(ctx context.Context, userID, sessionID, deviceName string) error
+	Revoke(ctx context.Context, userID, sessionID string) error
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+type sessionService struct {
+	repo        repository.SessionRepository
+	refreshRepo repository.RefreshTokenRepository
+	revocations repository.RevocationStore
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+func NewSessionService(r repository.SessionRepository, refreshRepo repository.RefreshTokenRepository, revocations repository.RevocationStore, accessTTL, refreshTTL time.Duration) *sessionService {
+	return &sessionService{
+		repo:        r,
+		refreshRepo: refreshRepo,
+		revocations: revocations,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+func (s *sessionService) Start(ctx context.Context, userID string, meta SessionMeta) (*entity.Session, error) {
+	session := &entity.Session{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		UserAgent:  meta.UserAgent,
+		IP:         meta.IP,
+		DeviceName: meta.DeviceName,
+		ExpiresAt:  time.Now().Add(s.refreshTTL),
+	}
+
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Touch records a refresh on the session and extends it by another refresh
+// token lifetime.
+func (s *sessionService) Touch(ctx context.Context, sessionID string) error {
+	return s.repo.Touch(ctx, sessionID, time.Now().Add(s.refreshTTL))
+}
+
+func (s *sessionService) List(ctx context.Context, userID string) ([]*entity.Session, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *sessionService) Rename(ctx context.Context, userID, sessionID, deviceName string) error {
+	return s.repo.Rename(ctx, userID, sessionID, deviceName)
+}
+
+// Revoke ends the session: its refresh tokens stop rotating and the access
+// tokens issued for it are denied until they would have expired anyway.
+func (s *sessionService) Revoke(ctx context.Context, userID, sessionID string) error {
+	if err := s.repo.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	if err := s.refreshRepo.RevokeBySession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	return s.revocations.RevokeToken(ctx, sessionID, time.Now().Add(s.accessTTL))
+}
+
+// RevokeAll ends every session of the user, including tokens issued before
+// sessions were tracked.
+func (s *sessionService) RevokeAll(ctx context.Context, userID string) error {
+	now := time.Now()
+	if err := s.revocations.RevokeUser(ctx, userID, now, now.Add(s.accessTTL)); err != nil {
+		return err
+	}
+
+	if err := s.refreshRepo.RevokeByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.repo.RevokeByUser(ctx, userID)
+}
@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/infra/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(ctx context.Context, id string) (*entity.Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListByUser(ctx context.Context, userID string) ([]*entity.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Touch(ctx context.Context, id string, expiresAt time.Time) error {
+	args := m.Called(ctx, id, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Rename(ctx context.Context, userID, id, deviceName string) error {
+	args := m.Called(ctx, userID, id, deviceName)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Denies the session tokens", func(t *testing.T) {
+		repo := new(MockSessionRepository)
+		refreshRepo := new(MockRefreshTokenRepository)
+		revocations := repository.NewMemoryRevocationStore()
+		sessionService := service.NewSessionService(repo, refreshRepo, revocations, time.Minute, time.Hour)
+
+		repo.On("Revoke", mock.Anything, "user-id", "session-id").Return(nil)
+		refreshRepo.On("RevokeBySession", mock.Anything, "session-id").Return(nil)
+
+		assert.NoError(t, sessionService.Revoke(ctx, "user-id", "session-id"))
+
+		revoked, err := revocations.IsRevoked(ctx, "jti", "session-id", "user-id", now)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = revocations.IsRevoked(ctx, "jti", "other-session-id", "user-id", now)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+
+		repo.AssertExpectations(t)
+		refreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown session", func(t *testing.T) {
+		repo := new(MockSessionRepository)
+		refreshRepo := new(MockRefreshTokenRepository)
+		sessionService := service.NewSessionService(repo, refreshRepo, repository.NewMemoryRevocationStore(), time.Minute, time.Hour)
+
+		repo.On("Revoke", mock.Anything, "user-id", "session-id").Return(assert.AnError)
+
+		assert.Error(t, sessionService.Revoke(ctx, "user-id", "session-id"))
+		refreshRepo.AssertNotCalled(t, "RevokeBySession", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Logout ends the session", func(t *testing.T) {
+		repo := new(MockSessionRepository)
+		refreshRepo := new(MockRefreshTokenRepository)
+		authService := newAuthService("access", "refresh", refreshRepo, repo)
+
+		claims := &service.Claims{
+			UserID:    "user-id",
+			SessionID: "session-id",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "jti",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+
+		repo.On("Revoke", mock.Anything, "user-id", "session-id").Return(nil)
+		refreshRepo.On("RevokeBySession", mock.Anything, "session-id").Return(nil)
+
+		assert.NoError(t, authService.Logout(ctx, claims))
+
+		// Another access token of the same session
+		claims.ID = "other-jti"
+		revoked, err := authService.IsRevoked(ctx, claims)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/jwks"
+)
+
+// Signer signs tokens with a single key and resolves that key back when
+// tokens are verified.
+type Signer interface {
+	KeyID() string
+	Sign(claims jwt.Claims) (string, error)
+	Keyfunc(token *jwt.Token) (any, error)
+	// JWKS lists the public verification keys, symmetric signers have none.
+	JWKS() jwks.Set
+}
+
+type signer struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	public    []jwks.Key
+}
+
+// NewHMACSigner signs with HS256. The kid is derived from the secret when
+// empty so tokens keep a stable header between restarts.
+func NewHMACSigner(kid, secret string) Signer {
+	if kid == "" {
+		sum := sha256.Sum256([]byte(secret))
+		kid = hex.EncodeToString(sum[:8])
+	}
+
+	return &signer{
+		kid:       kid,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// NewSigner signs with RS256, ES256/ES384/ES512 or EdDSA depending on the
+// private key type. An empty kid is replaced by the key thumbprint.
+func NewSigner(kid string, key crypto.Signer) (Signer, error) {
+	var method jwt.SigningMethod
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch key.Curve.Params().BitSize {
+		case 256:
+			method = jwt.SigningMethodES256
+		case 384:
+			method = jwt.SigningMethodES384
+		case 521:
+			method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", key.Curve.Params().Name)
+		}
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+
+	public, err := jwks.NewKey(kid, method.Alg(), key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &signer{
+		kid:       public.Kid,
+		method:    method,
+		signKey:   key,
+		verifyKey: key.Public(),
+		public:    []jwks.Key{public},
+	}, nil
+}
+
+// NewSignerFromPEM parses a PKCS#8, PKCS#1 or SEC 1 private key.
+func NewSignerFromPEM(kid string, data []byte) (Signer, error) {
+	key, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigner(kid, key)
+}
+
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		key any
+		err error
+	)
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+
+	return signer, nil
+}
+
+func (s *signer) KeyID() string {
+	return s.kid
+}
+
+func (s *signer) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.kid
+
+	return token.SignedString(s.signKey)
+}
+
+func (s *signer) Keyfunc(token *jwt.Token) (any, error) {
+	if token.Method.Alg() != s.method.Alg() {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	if kid, ok := token.Header["kid"].(string); ok && kid != s.kid {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	return s.verifyKey, nil
+}
+
+func (s *signer) JWKS() jwks.Set {
+	return jwks.Set{Keys: s.public}
+}
@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerVerifiesThroughJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{name: "RS256", key: rsaKey, alg: "RS256"},
+		{name: "ES256", key: ecKey, alg: "ES256"},
+		{name: "EdDSA", key: edKey, alg: "EdDSA"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			der, err := x509.MarshalPKCS8PrivateKey(tc.key)
+			require.NoError(t, err)
+
+			signer, err := service.NewSignerFromPEM("", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+			require.NoError(t, err)
+
+			signed, err := signer.Sign(service.Claims{
+				UserID: "user-id",
+				Type:   "access",
+				RegisteredClaims: jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+			})
+			require.NoError(t, err)
+
+			set := signer.JWKS()
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, tc.alg, set.Keys[0].Alg)
+
+			// Verify with the public key set only, as a downstream service would
+			token, err := jwt.ParseWithClaims(signed, &service.Claims{}, set.Keyfunc)
+			require.NoError(t, err)
+			assert.Equal(t, set.Keys[0].Kid, token.Header["kid"])
+			assert.Equal(t, "user-id", token.Claims.(*service.Claims).UserID)
+		})
+	}
+}
+
+func TestHMACSignerHasNoPublicKeys(t *testing.T) {
+	signer := service.NewHMACSigner("", "secret")
+
+	signed, err := signer.Sign(service.Claims{UserID: "user-id"})
+	require.NoError(t, err)
+
+	_, err = jwt.ParseWithClaims(signed, &service.Claims{}, signer.Keyfunc)
+	assert.NoError(t, err)
+	assert.Empty(t, signer.JWKS().Keys)
+
+	// A token signed with another secret must not verify
+	_, err = jwt.ParseWithClaims(signed, &service.Claims{}, service.NewHMACSigner("", "other").Keyfunc)
+	assert.Error(t, err)
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 
+	"github.com/google/uuid"
 	"github.com/leonardonicola/golerplate/internal/domain/entity"
 	"github.com/leonardonicola/golerplate/internal/dto"
 	"github.com/leonardonicola/golerplate/internal/infra/repository"
@@ -17,21 +19,45 @@ import (
 
 type UserService interface {
 	Create(ctx context.Context, dto dto.RegisterUserDTO) (*entity.User, error)
+	// Provision creates the user of a federated login, with a verified email
+	// and no password.
+	Provision(ctx context.Context, fullName, email string) (*entity.User, error)
 	GetByID(ctx context.Context, id string) (*entity.User, error)
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetByCPF(ctx context.Context, cpf string) (*entity.User, error)
 	Authenticate(ctx context.Context, email, password string) (*entity.User, error)
+	VerifyPassword(ctx context.Context, userID, password string) error
+	// CheckPassword checks a new password of the user against the password
+	// policy, violations are util.ValidationErrors.
+	CheckPassword(ctx context.Context, userID, password string) error
+	// SetPassword checks the new password like CheckPassword, then hashes and
+	// stores it.
+	SetPassword(ctx context.Context, userID, password string) error
+	MarkEmailVerified(ctx context.Context, userID string) error
+	// BumpTokenVersion refuses the refresh tokens issued to the user so far,
+	// AuthService.LogoutAll bumps it.
+	BumpTokenVersion(ctx context.Context, userID string) error
+	// SetDisabled disables or enables the account, it doesn't end the
+	// sessions already open.
+	SetDisabled(ctx context.Context, userID string, disabled bool) error
 }
 
 type userService struct {
 	repo   repository.UserRepository
+	hasher util.PasswordHasher
+	policy *util.PasswordPolicy
 	tracer oteltrace.Tracer
+	log    *log.Logger
 }
 
-func NewUserService(r repository.UserRepository) *userService {
+// NewUserService checks new passwords against policy, nil accepts any.
+func NewUserService(r repository.UserRepository, hasher util.PasswordHasher, policy *util.PasswordPolicy) *userService {
 	return &userService{
 		repo:   r,
+		hasher: hasher,
+		policy: policy,
 		tracer: otel.Tracer(constants.TRACER_NAME),
+		log:    log.Default(),
 	}
 }
 
@@ -39,8 +65,12 @@ func (s *userService) Create(ctx context.Context, dto dto.RegisterUserDTO) (*ent
 	ctx, span := s.tracer.Start(ctx, "CreateUser", oteltrace.WithAttributes(attribute.String("email", dto.Email)))
 	defer span.End()
 
+	if err := s.checkPolicy(dto.Password, dto.FullName, dto.Email, dto.CPF); err != nil {
+		return nil, err
+	}
+
 	ctx, hashSpan := s.tracer.Start(ctx, "HashPassword")
-	hashedPw, err := util.HashPassword(dto.Password)
+	hashedPw, err := s.hasher.Hash(dto.Password)
 	hashSpan.End()
 	if err != nil {
 		return nil, err
@@ -67,6 +97,15 @@ func (s *userService) Create(ctx context.Context, dto dto.RegisterUserDTO) (*ent
 	return user, nil
 }
 
+func (s *userService) Provision(ctx context.Context, fullName, email string) (*entity.User, error) {
+	user, err := entity.NewFederatedUser(fullName, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, user)
+}
+
 func (s *userService) GetByID(ctx context.Context, id string) (*entity.User, error) {
 	user, err := s.repo.GetByID(ctx, id)
 
@@ -105,7 +144,7 @@ func (s *userService) Authenticate(ctx context.Context, email, password string)
 		return nil, errors.New(constants.ErrMsgInvalidCredentials)
 	}
 
-	if !util.CheckPasswordEquality(password, user.Password) {
+	if !s.checkPassword(ctx, user, password) {
 		return nil, errors.New(constants.ErrMsgInvalidCredentials)
 	}
 
@@ -113,3 +152,100 @@ func (s *userService) Authenticate(ctx context.Context, email, password string)
 
 	return user, nil
 }
+
+func (s *userService) VerifyPassword(ctx context.Context, userID, password string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+
+	if err != nil {
+		return errors.New(constants.ErrMsgInvalidCredentials)
+	}
+
+	if !s.checkPassword(ctx, user, password) {
+		return errors.New(constants.ErrMsgInvalidCredentials)
+	}
+
+	return nil
+}
+
+// checkPassword verifies the password of the user and, while it is at hand
+// in clear, upgrades a hash made with an older algorithm or parameters, see
+// util.HashAlgorithm.
+func (s *userService) checkPassword(ctx context.Context, user *entity.User, password string) bool {
+	// Users provisioned by a federated login have no password to match
+	if user.Password == "" {
+		return false
+	}
+
+	ctx, span := s.tracer.Start(ctx, "VerifyPassword")
+	match, rehash, err := s.hasher.Verify(password, user.Password)
+	span.End()
+	if err != nil {
+		s.log.Printf("USER SERVICE: password of user %s: %s", user.ID, err.Error())
+		return false
+	}
+
+	if match && rehash {
+		// Best effort, retried next login, and the policy isn't checked again
+		if err := s.storePassword(ctx, user.ID, password); err != nil {
+			s.log.Printf("USER SERVICE: failed to rehash password of user %s: %s", user.ID, err.Error())
+		}
+	}
+
+	return match
+}
+
+func (s *userService) CheckPassword(ctx context.Context, userID, password string) error {
+	if s.policy == nil {
+		return nil
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.checkPolicy(password, user.FullName, user.Email, user.CPF)
+}
+
+func (s *userService) SetPassword(ctx context.Context, userID, password string) error {
+	if err := s.CheckPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	return s.storePassword(ctx, userID, password)
+}
+
+func (s *userService) checkPolicy(password string, personal ...string) error {
+	if s.policy == nil {
+		return nil
+	}
+
+	return s.policy.Check(password, personal...)
+}
+
+func (s *userService) storePassword(ctx context.Context, userID, password string) error {
+	ctx, span := s.tracer.Start(ctx, "HashPassword")
+	hashedPw, err := s.hasher.Hash(password)
+	span.End()
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePassword(ctx, userID, hashedPw)
+}
+
+func (s *userService) MarkEmailVerified(ctx context.Context, userID string) error {
+	return s.repo.MarkEmailVerified(ctx, userID)
+}
+
+func (s *userService) BumpTokenVersion(ctx context.Context, userID string) error {
+	return s.repo.BumpTokenVersion(ctx, userID)
+}
+
+func (s *userService) SetDisabled(ctx context.Context, userID string, disabled bool) error {
+	if uuid.Validate(userID) != nil {
+		return errors.New(constants.ErrMsgUserNotFound)
+	}
+
+	return s.repo.SetDisabled(ctx, userID, disabled)
+}
@@ -2,13 +2,17 @@ package service_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/leonardonicola/golerplate/internal/domain/entity"
 	"github.com/leonardonicola/golerplate/internal/domain/service"
 	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type MockUserRepository struct {
@@ -47,9 +51,36 @@ func (m *MockUserRepository) GetByCPF(ctx context.Context, cpf string) (*entity.
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id, password string) error {
+	args := m.Called(ctx, id, password)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) BumpTokenVersion(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	args := m.Called(ctx, id, disabled)
+	return args.Error(0)
+}
+
+// testHasher keeps tests fast, the minimum bcrypt cost.
+var testHasher = util.NewBcryptHasher(bcrypt.MinCost)
+
+func newUserService(repo *MockUserRepository) service.UserService {
+	return service.NewUserService(repo, testHasher, nil)
+}
+
 func TestCreateUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	userService := service.NewUserService(mockRepo)
+	userService := newUserService(mockRepo)
 
 	testCases := []struct {
 		name        string
@@ -112,3 +143,55 @@ func TestCreateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticateRehashes(t *testing.T) {
+	ctx := context.Background()
+
+	argon := util.NewArgon2idHasher(util.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	legacyHash, err := testHasher.Hash("password123")
+	assert.NoError(t, err)
+
+	t.Run("Upgrades a legacy hash on a successful login", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		repo.On("GetByEmail", mock.Anything, "test@example.com").Return(&entity.User{ID: "user-id", Password: legacyHash}, nil)
+		repo.On("UpdatePassword", mock.Anything, "user-id", mock.MatchedBy(func(hash string) bool {
+			match, rehash, err := argon.Verify("password123", hash)
+			return match && !rehash && err == nil
+		})).Return(nil).Once()
+
+		userService := service.NewUserService(repo, util.NewUpgradingHasher(argon, testHasher), nil)
+
+		_, err := userService.Authenticate(ctx, "test@example.com", "password123")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Upgrades bcrypt hashes whatever their prefix", func(t *testing.T) {
+		for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+			hash := prefix + strings.TrimPrefix(legacyHash, "$2a$")
+			require.Equal(t, util.AlgorithmBcrypt, util.HashAlgorithm(hash))
+
+			repo := new(MockUserRepository)
+			repo.On("GetByID", mock.Anything, "user-id").Return(&entity.User{ID: "user-id", Password: hash}, nil)
+			repo.On("UpdatePassword", mock.Anything, "user-id", mock.MatchedBy(func(hash string) bool {
+				return util.HashAlgorithm(hash) == util.AlgorithmArgon2id
+			})).Return(nil).Once()
+
+			userService := service.NewUserService(repo, util.NewUpgradingHasher(argon, testHasher), nil)
+
+			assert.NoError(t, userService.VerifyPassword(ctx, "user-id", "password123"), prefix)
+			repo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("Leaves the hash alone on a failed login", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		repo.On("GetByEmail", mock.Anything, "test@example.com").Return(&entity.User{ID: "user-id", Password: legacyHash}, nil)
+
+		userService := service.NewUserService(repo, util.NewUpgradingHasher(argon, testHasher), nil)
+
+		_, err := userService.Authenticate(ctx, "test@example.com", "wrong")
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "github.com/leonardonicola/golerplate/internal/domain/entity"
+
+type CreateAPIKeyDTO struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read write admin"`
+	// ExpiresInDays defaults to 90
+	ExpiresInDays int `json:"expires_in_days,omitempty" binding:"omitempty,min=1,max=365"`
+}
+
+type APIKeyCreatedDTO struct {
+	// Key is the plaintext key, it is only ever shown here
+	Key    string         `json:"key"`
+	APIKey *entity.APIKey `json:"api_key"`
+}
+
+type APIKeysResponseDTO struct {
+	APIKeys []*entity.APIKey `json:"api_keys"`
+}
@@ -1,13 +1,18 @@
 package dto
 
-import "github.com/leonardonicola/golerplate/internal/domain/entity"
+import (
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+)
 
 type RegisterUserDTO struct {
 	FullName string `json:"full_name" binding:"required,min=2,max=100"`
 	Email    string `json:"email" binding:"required,email"`
 	CPF      string `json:"cpf" binding:"required"`
 	Age      int    `json:"age" binding:"required,min=18,max=150"`
-	Password string `json:"password" binding:"required,min=6"`
+	// Password is checked against the password policy
+	Password string `json:"password" binding:"required"`
 }
 
 type ErrorResponseDTO struct {
@@ -19,15 +24,45 @@ type TokenResponseDTO struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// CookieSessionDTO answers logins in cookie mode, the tokens are cookies.
+type CookieSessionDTO struct {
+	// CSRFToken goes in the X-CSRF-Token header of state-changing requests
+	CSRFToken string `json:"csrf_token"`
+}
+
 type LoginRequestDTO struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"max=100"`
 }
 
 type RefreshRequestDTO struct {
+	// RefreshToken is read from its cookie in cookie mode
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// ReauthRequestDTO proves who the user is again, with either the password
+// or a TOTP or recovery code.
+type ReauthRequestDTO struct {
+	Password string `json:"password" binding:"required_without=Code"`
+	Code     string `json:"code" binding:"required_without=Password"`
+}
+
+type ReauthResponseDTO struct {
+	// AccessToken is set as a cookie instead in cookie mode
+	AccessToken string    `json:"access_token,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ReauthRequiredDTO answers requests that need a more recent login.
+type ReauthRequiredDTO struct {
+	Message string `json:"message"`
+	// Error is always reauth_required
+	Error string `json:"error"`
+	// MaxAge is how many seconds ago the user may have last authenticated
+	MaxAge int `json:"max_age"`
+}
+
 type RegisterResponseDTO struct {
 	User entity.User `json:"user"`
 }
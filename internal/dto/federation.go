@@ -0,0 +1,26 @@
+package dto
+
+import "github.com/leonardonicola/golerplate/internal/domain/entity"
+
+type IdentityProvidersResponseDTO struct {
+	Providers []string `json:"providers"`
+}
+
+// FederatedLoginDTO starts a login at an identity provider.
+type FederatedLoginDTO struct {
+	// AuthorizationURL is where to send the browser
+	AuthorizationURL string `json:"authorization_url"`
+	// Flow is sent back with the code and state the provider redirects with
+	Flow string `json:"flow"`
+}
+
+type FinishFederatedLoginDTO struct {
+	Flow       string `json:"flow" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"max=100"`
+}
+
+type IdentitiesResponseDTO struct {
+	Identities []*entity.Identity `json:"identities"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+)
+
+type ImpersonationResponseDTO struct {
+	// AccessToken acts as the user until ExpiresAt, it can't be refreshed
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type ImpersonationAuditResponseDTO struct {
+	Entries []*entity.ImpersonationAudit `json:"entries"`
+}
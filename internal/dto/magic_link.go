@@ -0,0 +1,10 @@
+package dto
+
+type MagicLinkRequestDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ConsumeMagicLinkDTO struct {
+	Token      string `json:"token" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"max=100"`
+}
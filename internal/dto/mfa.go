@@ -0,0 +1,31 @@
+package dto
+
+type TOTPEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	// QRPayload is a PNG data URI of the otpauth URI
+	QRPayload string `json:"qr_payload"`
+}
+
+type MFACodeRequestDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponseDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableMFARequestDTO struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type MFARequiredResponseDTO struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type LoginMFARequestDTO struct {
+	MFAToken   string `json:"mfa_token" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"max=100"`
+}
@@ -0,0 +1,98 @@
+package dto
+
+import "github.com/leonardonicola/golerplate/internal/domain/entity"
+
+type CreateOAuthClientDTO struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Public clients get no secret and can only use authorization_code
+	Public       bool     `json:"public"`
+	RedirectURIs []string `json:"redirect_uris" binding:"omitempty,dive,url"`
+	Scopes       []string `json:"scopes" binding:"omitempty,dive,required,max=100"`
+}
+
+type OAuthClientCreatedDTO struct {
+	// ClientSecret is only ever shown here, empty for public clients
+	ClientSecret string              `json:"client_secret,omitempty"`
+	Client       *entity.OAuthClient `json:"client"`
+}
+
+type OAuthClientsResponseDTO struct {
+	Clients []*entity.OAuthClient `json:"clients"`
+}
+
+// AuthorizeRequestDTO is read from the query of GET /oauth/authorize, and
+// posted back as JSON by the consent page.
+type AuthorizeRequestDTO struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	// Nonce is repeated in the ID token of OpenID Connect requests
+	Nonce string `form:"nonce" json:"nonce" binding:"max=255"`
+	// Denied answers the client with access_denied
+	Denied bool `form:"-" json:"denied"`
+}
+
+type AuthorizeResponseDTO struct {
+	// RedirectURI is where the consent page sends the browser next
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// TokenRequestDTO is form encoded, the client credentials are read apart.
+type TokenRequestDTO struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+type OAuthTokenResponseDTO struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// IDToken is issued when the openid scope was granted
+	IDToken string `json:"id_token,omitempty"`
+}
+
+// IntrospectRequestDTO is form encoded, as RFC 7662 asks.
+type IntrospectRequestDTO struct {
+	Token string `form:"token" binding:"required"`
+	// TokenTypeHint is accepted for compatibility, every kind is tried
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+// IntrospectionResponseDTO only carries Active when the token isn't.
+type IntrospectionResponseDTO struct {
+	Active   bool   `json:"active"`
+	Sub      string `json:"sub,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// Scope is space separated
+	Scope string `json:"scope,omitempty"`
+	// TokenType is access_token, refresh_token or api_key. Sub is the client
+	// for access tokens of the client_credentials grant.
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	// Act names the admin impersonating the user, as in RFC 8693
+	Act *IntrospectionActorDTO `json:"act,omitempty"`
+}
+
+type IntrospectionActorDTO struct {
+	Sub string `json:"sub"`
+}
+
+// OAuthErrorDTO is the error body of RFC 6749, stock OAuth clients expect it
+// from the OAuth endpoints.
+type OAuthErrorDTO struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
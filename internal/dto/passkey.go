@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+)
+
+// PasskeyRegistrationDTO holds the options for navigator.credentials.create
+// and the ceremony token to send back with the result.
+type PasskeyRegistrationDTO struct {
+	Ceremony string                       `json:"ceremony"`
+	Options  *protocol.CredentialCreation `json:"options" swaggertype:"object"`
+}
+
+// PasskeyLoginDTO holds the options for navigator.credentials.get and the
+// ceremony token to send back with the result.
+type PasskeyLoginDTO struct {
+	Ceremony string                        `json:"ceremony"`
+	Options  *protocol.CredentialAssertion `json:"options" swaggertype:"object"`
+}
+
+type FinishPasskeyRegistrationDTO struct {
+	Ceremony string `json:"ceremony" binding:"required"`
+	Name     string `json:"name,omitempty" binding:"max=100"`
+	// Credential is the PublicKeyCredential returned by the browser
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type FinishPasskeyLoginDTO struct {
+	Ceremony   string          `json:"ceremony" binding:"required"`
+	DeviceName string          `json:"device_name,omitempty" binding:"max=100"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type PasskeysResponseDTO struct {
+	Passkeys []*entity.WebAuthnCredential `json:"passkeys"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordDTO struct {
+	Token string `json:"token" binding:"required"`
+	// Password is checked against the password policy
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// NewPassword is checked against the password policy
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type MessageResponseDTO struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/leonardonicola/golerplate/internal/domain/entity"
+
+type RolesResponseDTO struct {
+	Roles []*entity.Role `json:"roles"`
+}
+
+type UserRolesResponseDTO struct {
+	Roles []string `json:"roles"`
+}
+
+type AssignRoleDTO struct {
+	Role string `json:"role" binding:"required,max=50"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+)
+
+// SecurityEventQueryDTO is read from the query of the security event
+// listings, empty fields match everything.
+type SecurityEventQueryDTO struct {
+	UserID  string    `form:"user_id"`
+	ActorID string    `form:"actor_id"`
+	Type    string    `form:"type"`
+	Outcome string    `form:"outcome" binding:"omitempty,oneof=success failure"`
+	IP      string    `form:"ip"`
+	Since   time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until   time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Cursor is the next_cursor of the previous page
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+type SecurityEventPageDTO struct {
+	Events []*entity.SecurityEvent `json:"events"`
+	// NextCursor fetches the older events, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
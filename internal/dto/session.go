@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/leonardonicola/golerplate/internal/domain/entity"
+
+type SessionsResponseDTO struct {
+	Sessions []*entity.Session `json:"sessions"`
+}
+
+type RenameSessionDTO struct {
+	DeviceName string `json:"device_name" binding:"required,max=100"`
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	log           *log.Logger
+}
+
+func NewAPIKeyHandler(ks service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: ks,
+		log:           log.Default(),
+	}
+}
+
+// Create API Key godoc
+//
+//	@Summary		Create an API key
+//	@Description	Create a named, scoped and expiring API key, the key is only shown in this response. Requires a login or a /reauth within 5 minutes
+//	@Tags			tokens
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.CreateAPIKeyDTO		true	"Name, scopes and lifetime"
+//	@Success		201		{object}	dto.APIKeyCreatedDTO	"Plaintext key and its metadata"
+//	@Failure		401		{object}	dto.ErrorResponseDTO	"Unauthorized, or reauth_required"
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Router			/tokens [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.CreateAPIKeyDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	created, err := h.apiKeyService.Create(c.Request.Context(), claims.UserID, req)
+	if err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// List API Keys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the active API keys of the current user
+//	@Tags			tokens
+//	@Produce		json
+//	@Success		200	{object}	dto.APIKeysResponseDTO	"Active API keys"
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Router			/tokens [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), claims.UserID)
+	if err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIKeysResponseDTO{APIKeys: keys})
+}
+
+// Revoke API Key godoc
+//
+//	@Summary		Revoke an API key
+//	@Description	Revoke one of the current user's API keys
+//	@Tags			tokens
+//	@Produce		json
+//	@Param			id	path	string	true	"API key ID"
+//	@Success		204
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"API key not found"
+//	@Router			/tokens/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), claims.UserID, c.Param("id")); err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIKeyHandler) apiKeyError(c *gin.Context, err error) {
+	if err.Error() == constants.ErrMsgAPIKeyNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("API KEY SERVICE: %s", err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
@@ -1,40 +1,64 @@
 package handler
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
 	"github.com/leonardonicola/golerplate/internal/domain/service"
 	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
 	"github.com/leonardonicola/golerplate/pkg/util"
 )
 
 type AuthHandler struct {
 	userService  service.UserService
 	tokenService service.AuthService
+	mfaService   service.MFAService
+	throttle     service.LoginThrottleService
+	events       service.SecurityEventService
+	cookies      *TokenCookies
 	log          *log.Logger
 }
 
-func NewAuthHandler(us service.UserService, ts service.AuthService) *AuthHandler {
+func NewAuthHandler(us service.UserService, ts service.AuthService, ms service.MFAService, throttle service.LoginThrottleService, es service.SecurityEventService, cookies *TokenCookies) *AuthHandler {
 	return &AuthHandler{
 		userService:  us,
 		tokenService: ts,
+		mfaService:   ms,
+		throttle:     throttle,
+		events:       es,
+		cookies:      cookies,
 		log:          log.Default(),
 	}
 }
 
+// mfaCodeAttempts is how many wrong codes burn an MFA token, the password
+// has to be given again for a new one.
+const mfaCodeAttempts = 3
+
 // Login godoc
 //
 //	@Summary		Login user
-//	@Description	Authenticate a user and return access tokens
+//	@Description	Authenticate a user and return access tokens, or an MFA token when two-factor is enabled. In cookie mode the tokens are set as cookies and only the CSRF token is returned
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		dto.LoginRequestDTO		true	"Login credentials"
-//	@Success		200		{object}	dto.TokenResponseDTO	"Successfully authenticated"
+//	@Param			request	body		dto.LoginRequestDTO			true	"Login credentials"
+//	@Success		200		{object}	dto.TokenResponseDTO		"Successfully authenticated"
+//	@Success		202		{object}	dto.MFARequiredResponseDTO	"Second factor required"
 //	@Failure		400		{object}	dto.ErrorResponseDTO	"Bad request"
 //	@Failure		401		{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		403		{object}	dto.ErrorResponseDTO	"Email not verified"
+//	@Failure		429		{object}	dto.ErrorResponseDTO	"Too many failed attempts, see Retry-After"
 //	@Router			/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req dto.LoginRequestDTO
@@ -45,29 +69,178 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	wait, err := h.throttle.Attempt(c.Request.Context(), req.Email, c.ClientIP())
+	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if wait > 0 {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLogin, Outcome: entity.OutcomeFailure, UserID: h.userIDByEmail(c, req.Email), Reason: constants.ErrMsgTooManyAttempts,
+		})
+
+		retryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": constants.ErrMsgTooManyAttempts})
+		return
+	}
+
 	user, err := h.userService.Authenticate(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
 		h.log.Printf("USER SERVICE: %s", err.Error())
 
+		userID := h.userIDByEmail(c, req.Email)
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLogin, Outcome: entity.OutcomeFailure, UserID: userID, Reason: err.Error(),
+		})
+
+		wait, locked, throttleErr := h.throttle.Failure(c.Request.Context(), req.Email, c.ClientIP())
+		if throttleErr != nil {
+			h.log.Printf("LOGIN THROTTLE: %s", throttleErr.Error())
+		}
+		if locked {
+			recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+				Type: entity.EventLockout, Outcome: entity.OutcomeSuccess, UserID: userID,
+			})
+		}
+		if wait > 0 {
+			retryAfter(c, wait)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	mfaEnabled, err := h.mfaService.Enabled(c.Request.Context(), user.ID)
+	if err != nil {
+		h.log.Printf("MFA SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if mfaEnabled {
+		mfaToken, err := h.tokenService.MFAToken(user)
+		if err != nil {
+			h.log.Printf("AUTH SERVICE: %s", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		// The failures are only forgotten once the second factor is given too
+		if err := h.throttle.Forgive(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+			h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		}
+
+		c.JSON(http.StatusAccepted, dto.MFARequiredResponseDTO{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
+
+	if err := h.throttle.Success(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+
+	token, err := h.tokenService.GenerateToken(c.Request.Context(), user, sessionMeta(c, req.DeviceName))
+
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLogin, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: err.Error(),
+		})
+		tokenError(c, h.log, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLogin, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	h.cookies.writeTokens(c, token)
+}
+
+// Login MFA godoc
+//
+//	@Summary		Complete a two-factor login
+//	@Description	Exchange the MFA token from /login and a TOTP or recovery code for access tokens
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.LoginMFARequestDTO	true	"MFA token and code"
+//	@Success		200		{object}	dto.TokenResponseDTO	"Successfully authenticated"
+//	@Failure		401		{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		403		{object}	dto.ErrorResponseDTO	"Email not verified"
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Router			/login/mfa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.LoginMFARequestDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	claims, err := h.tokenService.ParseMFAToken(ctx, req.MFAToken)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 		return
 	}
 
-	token, err := h.tokenService.GenerateToken(user)
+	user, err := h.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
 
+	// Codes are guessed like passwords, they count against the same limits
+	wait, err := h.throttle.Attempt(ctx, user.Email, c.ClientIP())
 	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if wait > 0 {
+		retryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": constants.ErrMsgTooManyAttempts})
+		return
+	}
+
+	if err := h.mfaService.Verify(ctx, user.ID, req.Code); err != nil {
+		h.log.Printf("MFA SERVICE: %s", err.Error())
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginMFA, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: constants.ErrMsgInvalidMFACode,
+		})
+		h.mfaFailure(c, user, claims)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidMFACode})
+		return
+	}
+
+	// The MFA token has no session, logging it out only burns its jti
+	if err := h.tokenService.Logout(ctx, claims); err != nil {
 		h.log.Printf("AUTH SERVICE: %s", err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, token)
+	if err := h.throttle.Success(ctx, user.Email, c.ClientIP()); err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+
+	token, err := h.tokenService.GenerateToken(ctx, user, sessionMeta(c, req.DeviceName))
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginMFA, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: err.Error(),
+		})
+		tokenError(c, h.log, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLoginMFA, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	h.cookies.writeTokens(c, token)
 }
 
 // Refresh Token godoc
 //
 //	@Summary		Refresh access token
-//	@Description	Refresh access token using refresh token
+//	@Description	Refresh access token using refresh token, read from its cookie in cookie mode
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
@@ -75,20 +248,411 @@ func (h *AuthHandler) Login(c *gin.Context) {
 //	@Success		200		{object}	dto.TokenResponseDTO	"Successfully refreshed tokens"
 //	@Failure		400		{object}	dto.ErrorResponseDTO	"Bad request"
 //	@Failure		401		{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		403		{object}	dto.ErrorResponseDTO	"Email not verified or account disabled"
 //	@Router			/refresh [post]
 func (h *AuthHandler) Refresh(c *gin.Context) {
-	var req dto.RefreshRequestDTO
+	refreshToken, ok := h.cookies.refreshToken(c)
+	if !ok {
+		var req dto.RefreshRequestDTO
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		refreshToken = req.RefreshToken
+	}
+
+	token, err := h.tokenService.RefreshToken(c.Request.Context(), refreshToken, "")
+	if err != nil {
+		event := &entity.SecurityEvent{Type: entity.EventRefresh, Outcome: entity.OutcomeFailure, Reason: err.Error()}
+		if err.Error() == constants.ErrMsgTokenReused {
+			// The signature checked out, only the token was used before
+			event.Type = entity.EventTokenReuse
+			event.UserID = refreshSubject(refreshToken)
+		}
+		recordEvent(c, h.events, h.log, event)
+
+		if err.Error() == constants.ErrMsgEmailNotVerified || err.Error() == constants.ErrMsgAccountDisabled {
+			c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+		Type: entity.EventRefresh, Outcome: entity.OutcomeSuccess, UserID: refreshSubject(refreshToken),
+	})
+	h.cookies.writeTokens(c, token)
+}
+
+// Reauth godoc
+//
+//	@Summary		Re-authenticate
+//	@Description	Prove who you are again with the password or a TOTP or recovery code, to get an access token with a recent auth_time. Changing the password, two-factor, passkeys, linked identities or API keys, or signing out every session asks for one. In cookie mode the token is set as a cookie
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.ReauthRequestDTO	true	"Password or code"
+//	@Success		200		{object}	dto.ReauthResponseDTO	"Access token with a recent auth_time"
+//	@Failure		401		{object}	dto.ErrorResponseDTO	"Wrong password or code"
+//	@Failure		403		{object}	dto.ErrorResponseDTO	"Account disabled"
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Failure		429		{object}	dto.ErrorResponseDTO	"Too many failed attempts, see Retry-After"
+//	@Router			/reauth [post]
+func (h *AuthHandler) Reauth(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.ReauthRequestDTO
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
 		return
 	}
 
-	token, err := h.tokenService.RefreshToken(req.RefreshToken)
+	user, err := h.userService.GetByID(ctx, claims.UserID)
 	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	// Guessing counts as failed logins, a stolen access token doesn't open
+	// unlimited attempts at the password
+	wait, err := h.throttle.Attempt(ctx, user.Email, c.ClientIP())
+	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if wait > 0 {
+		retryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": constants.ErrMsgTooManyAttempts})
+		return
+	}
+
+	if req.Password != "" {
+		err = h.userService.VerifyPassword(ctx, user.ID, req.Password)
+	} else if err = h.mfaService.Verify(ctx, user.ID, req.Code); err != nil {
+		h.log.Printf("MFA SERVICE: %s", err.Error())
+		err = errors.New(constants.ErrMsgInvalidMFACode)
+	}
+
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventReauth, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: err.Error(),
+		})
+
+		wait, locked, throttleErr := h.throttle.Failure(ctx, user.Email, c.ClientIP())
+		if throttleErr != nil {
+			h.log.Printf("LOGIN THROTTLE: %s", throttleErr.Error())
+		}
+		if locked {
+			recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+				Type: entity.EventLockout, Outcome: entity.OutcomeSuccess, UserID: user.ID,
+			})
+		}
+		if wait > 0 {
+			retryAfter(c, wait)
+		}
+
 		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, token)
+	if err := h.throttle.Success(ctx, user.Email, c.ClientIP()); err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+
+	token, expiresAt, err := h.tokenService.ReauthToken(ctx, claims)
+	if err != nil {
+		if err.Error() == constants.ErrMsgInvalidToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			return
+		}
+
+		tokenError(c, h.log, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventReauth, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	h.cookies.writeAccessToken(c, token, expiresAt)
+}
+
+// Logout godoc
+//
+//	@Summary		Logout
+//	@Description	Revoke the current access token and its refresh token
+//	@Tags			auth
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		500	{object}	dto.ErrorResponseDTO	"Internal server error"
+//	@Router			/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	if err := h.tokenService.Logout(c.Request.Context(), claims); err != nil {
+		h.log.Printf("AUTH SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLogout, Outcome: entity.OutcomeSuccess, UserID: claims.UserID})
+	h.cookies.clear(c)
+	c.Status(http.StatusNoContent)
+}
+
+// Logout All godoc
+//
+//	@Summary		Logout from every device
+//	@Description	Revoke every access and refresh token issued to the current user, requires a login or a /reauth within 5 minutes
+//	@Tags			auth
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized, or reauth_required"
+//	@Failure		500	{object}	dto.ErrorResponseDTO	"Internal server error"
+//	@Router			/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	if err := h.tokenService.LogoutAll(c.Request.Context(), claims.UserID); err != nil {
+		h.log.Printf("AUTH SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLogoutAll, Outcome: entity.OutcomeSuccess, UserID: claims.UserID})
+	h.cookies.clear(c)
+	c.Status(http.StatusNoContent)
+}
+
+// Unlock Account godoc
+//
+//	@Summary		Unlock an account
+//	@Description	Clear the failed logins and the temporary lock of any user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"User not found"
+//	@Router			/admin/users/{userId}/lockout [delete]
+func (h *AuthHandler) Unlock(c *gin.Context) {
+	user, err := h.userService.GetByID(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": constants.ErrMsgUserNotFound})
+		return
+	}
+
+	if err := h.throttle.Unlock(c.Request.Context(), user.Email); err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("SECURITY: account %s unlocked by an admin", user.ID)
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventAccountUnlock, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	c.Status(http.StatusNoContent)
+}
+
+// Disable Account godoc
+//
+//	@Summary		Disable an account
+//	@Description	Refuse the logins of any user and revoke every token issued to them
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"User not found"
+//	@Router			/admin/users/{userId}/disable [post]
+func (h *AuthHandler) Disable(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userId")
+
+	if err := h.userService.SetDisabled(ctx, userID, true); err != nil {
+		h.disableError(c, err)
+		return
+	}
+
+	if err := h.tokenService.LogoutAll(ctx, userID); err != nil {
+		h.log.Printf("AUTH SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("SECURITY: account %s disabled by an admin", userID)
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventAccountDisable, Outcome: entity.OutcomeSuccess, UserID: userID})
+	c.Status(http.StatusNoContent)
+}
+
+// Enable Account godoc
+//
+//	@Summary		Enable an account
+//	@Description	Let a disabled user log in again
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"User not found"
+//	@Router			/admin/users/{userId}/enable [post]
+func (h *AuthHandler) Enable(c *gin.Context) {
+	userID := c.Param("userId")
+
+	if err := h.userService.SetDisabled(c.Request.Context(), userID, false); err != nil {
+		h.disableError(c, err)
+		return
+	}
+
+	h.log.Printf("SECURITY: account %s enabled by an admin", userID)
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventAccountEnable, Outcome: entity.OutcomeSuccess, UserID: userID})
+	c.Status(http.StatusNoContent)
+}
+
+// mfaFailure counts a wrong code against the account and the IP, and burns
+// the MFA token after mfaCodeAttempts of them.
+func (h *AuthHandler) mfaFailure(c *gin.Context, user *entity.User, claims *service.Claims) {
+	ctx := c.Request.Context()
+
+	wait, locked, err := h.throttle.Failure(ctx, user.Email, c.ClientIP())
+	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+	if locked {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLockout, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	}
+	if wait > 0 {
+		retryAfter(c, wait)
+	}
+
+	failures, err := h.throttle.TokenFailure(ctx, claims.ID)
+	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+
+	// Burnt as well when the failures couldn't be counted
+	if err != nil || failures >= mfaCodeAttempts {
+		if err := h.tokenService.Logout(ctx, claims); err != nil {
+			h.log.Printf("AUTH SERVICE: %s", err.Error())
+		}
+	}
+}
+
+// userIDByEmail names the account a failed login was aimed at, empty when
+// there is none.
+func (h *AuthHandler) userIDByEmail(c *gin.Context, email string) string {
+	user, err := h.userService.GetByEmail(c.Request.Context(), email)
+	if err != nil {
+		return ""
+	}
+
+	return user.ID
+}
+
+// refreshSubject reads the user of a refresh token the auth service already
+// verified, without checking it again.
+func refreshSubject(token string) string {
+	claims := &service.Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return ""
+	}
+
+	return claims.UserID
+}
+
+func (h *AuthHandler) disableError(c *gin.Context, err error) {
+	if err.Error() == constants.ErrMsgUserNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("USER SERVICE: %s", err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
+
+// tokenError answers a login that passed every check but got no tokens.
+func tokenError(c *gin.Context, logger *log.Logger, err error) {
+	if err.Error() == constants.ErrMsgEmailNotVerified || err.Error() == constants.ErrMsgAccountDisabled {
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	}
+
+	logger.Printf("AUTH SERVICE: %s", err.Error())
+	c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+}
+
+// retryAfter tells the client how many seconds to wait, rounded up.
+func retryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+// currentClaims rebuilds the claims the services take from the principal
+// stored by JWTAuthMiddleware.
+func currentClaims(c *gin.Context) (*service.Claims, bool) {
+	principal, ok := auth.FromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	claims := &service.Claims{
+		UserID:           principal.UserID,
+		Type:             principal.TokenType,
+		FamilyID:         principal.FamilyID,
+		SessionID:        principal.SessionID,
+		Roles:            principal.Roles,
+		Unverified:       principal.Unverified,
+		ClientID:         principal.ClientID,
+		Scope:            strings.Join(principal.Scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{ID: principal.TokenID},
+	}
+	if principal.Impersonated() {
+		claims.Act = &service.Actor{UserID: principal.ActorID}
+	}
+	if !principal.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(principal.AuthTime)
+	}
+	if !principal.ExpiresAt.IsZero() {
+		claims.ExpiresAt = jwt.NewNumericDate(principal.ExpiresAt)
+	}
+
+	return claims, true
+}
+
+// validationFailed answers 422 when err lists the failures of checks made
+// past request binding, such as the password policy.
+func validationFailed(c *gin.Context, err error) bool {
+	var violations util.ValidationErrors
+	if !errors.As(err, &violations) {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(violations))
+	return true
+}
+
+// sessionMeta describes the device the request comes from.
+func sessionMeta(c *gin.Context, deviceName string) service.SessionMeta {
+	return service.SessionMeta{
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		DeviceName: deviceName,
+	}
 }
@@ -8,14 +8,20 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
 	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
 	"github.com/leonardonicola/golerplate/internal/dto"
 	"github.com/leonardonicola/golerplate/internal/handler"
+	"github.com/leonardonicola/golerplate/internal/middleware"
 	"github.com/leonardonicola/golerplate/pkg/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockUserService struct {
@@ -30,6 +36,14 @@ func (m *MockUserService) Create(ctx context.Context, dto dto.RegisterUserDTO) (
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserService) Provision(ctx context.Context, fullName, email string) (*entity.User, error) {
+	args := m.Called(ctx, fullName, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
 func (m *MockUserService) GetByID(ctx context.Context, id string) (*entity.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -62,33 +76,224 @@ func (m *MockUserService) Authenticate(ctx context.Context, email, password stri
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserService) VerifyPassword(ctx context.Context, userID, password string) error {
+	args := m.Called(ctx, userID, password)
+	return args.Error(0)
+}
+
+func (m *MockUserService) CheckPassword(ctx context.Context, userID, password string) error {
+	args := m.Called(ctx, userID, password)
+	return args.Error(0)
+}
+
+func (m *MockUserService) SetPassword(ctx context.Context, userID, password string) error {
+	args := m.Called(ctx, userID, password)
+	return args.Error(0)
+}
+
+func (m *MockUserService) MarkEmailVerified(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) BumpTokenVersion(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) SetDisabled(ctx context.Context, userID string, disabled bool) error {
+	args := m.Called(ctx, userID, disabled)
+	return args.Error(0)
+}
+
 type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) GenerateToken(user *entity.User) (*dto.TokenResponseDTO, error) {
-	args := m.Called(user)
+func (m *MockAuthService) GenerateToken(ctx context.Context, user *entity.User, meta service.SessionMeta) (*dto.TokenResponseDTO, error) {
+	args := m.Called(ctx, user, meta)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dto.TokenResponseDTO), args.Error(1)
 }
 
-func (m *MockAuthService) RefreshToken(token string) (*dto.TokenResponseDTO, error) {
-	args := m.Called(token)
+func (m *MockAuthService) GrantToken(ctx context.Context, user *entity.User, meta service.SessionMeta, grant service.OAuthGrant) (*dto.TokenResponseDTO, error) {
+	args := m.Called(ctx, user, meta, grant)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dto.TokenResponseDTO), args.Error(1)
 }
 
+func (m *MockAuthService) RefreshToken(ctx context.Context, token, clientID string) (*dto.TokenResponseDTO, error) {
+	args := m.Called(ctx, token, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.TokenResponseDTO), args.Error(1)
+}
+
+func (m *MockAuthService) ClientToken(client *entity.OAuthClient, scope string) (string, error) {
+	args := m.Called(client, scope)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, claims *service.Claims) error {
+	args := m.Called(ctx, claims)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutAll(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) IsRevoked(ctx context.Context, claims *service.Claims) (bool, error) {
+	args := m.Called(ctx, claims)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthService) MFAToken(user *entity.User) (string, error) {
+	args := m.Called(user)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) ImpersonationToken(user *entity.User, actorID string) (string, time.Time, error) {
+	args := m.Called(user, actorID)
+	return args.String(0), args.Get(1).(time.Time), args.Error(2)
+}
+
+func (m *MockAuthService) ReauthToken(ctx context.Context, claims *service.Claims) (string, time.Time, error) {
+	args := m.Called(ctx, claims)
+	return args.String(0), args.Get(1).(time.Time), args.Error(2)
+}
+
+func (m *MockAuthService) ParseMFAToken(ctx context.Context, token string) (*service.Claims, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Claims), args.Error(1)
+}
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Enabled(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFAService) EnrollTOTP(ctx context.Context, user *entity.User) (*dto.TOTPEnrollmentDTO, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.TOTPEnrollmentDTO), args.Error(1)
+}
+
+func (m *MockMFAService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(ctx context.Context, userID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, userID, password string) error {
+	args := m.Called(ctx, userID, password)
+	return args.Error(0)
+}
+
+// recordedEvents keeps the recorded security events in memory.
+type recordedEvents struct {
+	events []*entity.SecurityEvent
+}
+
+func (r *recordedEvents) Record(ctx context.Context, event *entity.SecurityEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordedEvents) List(ctx context.Context, query dto.SecurityEventQueryDTO) (*dto.SecurityEventPageDTO, error) {
+	return &dto.SecurityEventPageDTO{Events: r.events}, nil
+}
+
+func (r *recordedEvents) ListForUser(ctx context.Context, userID string, query dto.SecurityEventQueryDTO) (*dto.SecurityEventPageDTO, error) {
+	return &dto.SecurityEventPageDTO{Events: r.events}, nil
+}
+
+type MockLoginThrottleService struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginThrottleService) Attempt(ctx context.Context, email, ip string) (time.Duration, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginThrottleService) Failure(ctx context.Context, email, ip string) (time.Duration, bool, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Bool(1), args.Error(2)
+}
+
+func (m *MockLoginThrottleService) Success(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleService) Forgive(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleService) SendAttempt(ctx context.Context, email string) (time.Duration, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginThrottleService) TokenFailure(ctx context.Context, jti string) (int, error) {
+	args := m.Called(ctx, jti)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLoginThrottleService) Unlock(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+// openThrottle never slows a login down.
+func openThrottle() *MockLoginThrottleService {
+	throttle := new(MockLoginThrottleService)
+	throttle.On("Check", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil).Maybe()
+	throttle.On("Attempt", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil).Maybe()
+	throttle.On("Failure", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), false, nil).Maybe()
+	throttle.On("Success", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	throttle.On("Forgive", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	throttle.On("SendAttempt", mock.Anything, mock.Anything).Return(time.Duration(0), nil).Maybe()
+	throttle.On("TokenFailure", mock.Anything, mock.Anything).Return(1, nil).Maybe()
+	return throttle
+}
+
 func TestRegister(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
 		name           string
 		requestBody    dto.LoginRequestDTO
-		setupMock      func(*MockUserService, *MockAuthService)
+		setupMock      func(*MockUserService, *MockAuthService, *MockMFAService)
 		expectedStatus int
 		expectedBody   any
 	}{
@@ -98,7 +303,7 @@ func TestRegister(t *testing.T) {
 				Email:    "test@example.com",
 				Password: "password123",
 			},
-			setupMock: func(mus *MockUserService, mas *MockAuthService) {
+			setupMock: func(mus *MockUserService, mas *MockAuthService, mms *MockMFAService) {
 				user := &entity.User{
 					ID:    "192391239",
 					Email: "test@example.com",
@@ -110,7 +315,8 @@ func TestRegister(t *testing.T) {
 				}
 
 				mus.On("Authenticate", mock.Anything, "test@example.com", "password123").Return(user, nil)
-				mas.On("GenerateToken", user).Return(token, nil)
+				mms.On("Enabled", mock.Anything, "192391239").Return(false, nil)
+				mas.On("GenerateToken", mock.Anything, user, mock.AnythingOfType("service.SessionMeta")).Return(token, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: dto.TokenResponseDTO{
@@ -118,15 +324,38 @@ func TestRegister(t *testing.T) {
 				RefreshToken: "refresh-token",
 			},
 		},
+		{
+			name: "Second factor required",
+			requestBody: dto.LoginRequestDTO{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			setupMock: func(mus *MockUserService, mas *MockAuthService, mms *MockMFAService) {
+				user := &entity.User{
+					ID:    "192391239",
+					Email: "test@example.com",
+				}
+
+				mus.On("Authenticate", mock.Anything, "test@example.com", "password123").Return(user, nil)
+				mms.On("Enabled", mock.Anything, "192391239").Return(true, nil)
+				mas.On("MFAToken", user).Return("mfa-token", nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody: dto.MFARequiredResponseDTO{
+				MFARequired: true,
+				MFAToken:    "mfa-token",
+			},
+		},
 		{
 			name: "Invalid credentials",
 			requestBody: dto.LoginRequestDTO{
 				Email:    "test@example.com",
 				Password: "wrongpassword",
 			},
-			setupMock: func(us *MockUserService, as *MockAuthService) {
+			setupMock: func(us *MockUserService, as *MockAuthService, ms *MockMFAService) {
 				us.On("Authenticate", mock.Anything, "test@example.com", "wrongpassword").
 					Return(nil, errors.New(constants.ErrMsgInvalidCredentials))
+				us.On("GetByEmail", mock.Anything, "test@example.com").Return(&entity.User{ID: "192391239"}, nil)
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   gin.H{"message": constants.ErrMsgInvalidCredentials},
@@ -137,9 +366,10 @@ func TestRegister(t *testing.T) {
 				Email:    "nonexistent@example.com",
 				Password: "password123",
 			},
-			setupMock: func(us *MockUserService, as *MockAuthService) {
+			setupMock: func(us *MockUserService, as *MockAuthService, ms *MockMFAService) {
 				us.On("Authenticate", mock.Anything, "nonexistent@example.com", "password123").
 					Return(nil, errors.New(constants.ErrMsgUserNotFound))
+				us.On("GetByEmail", mock.Anything, "nonexistent@example.com").Return(nil, errors.New(constants.ErrMsgUserNotFound))
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   gin.H{"message": constants.ErrMsgUserNotFound},
@@ -151,9 +381,10 @@ func TestRegister(t *testing.T) {
 			// Instantiate the mocks.
 			userService := new(MockUserService)
 			authService := new(MockAuthService)
-			handler := handler.NewAuthHandler(userService, authService)
+			mfaService := new(MockMFAService)
+			handler := handler.NewAuthHandler(userService, authService, mfaService, openThrottle(), new(recordedEvents), nil)
 
-			tt.setupMock(userService, authService)
+			tt.setupMock(userService, authService, mfaService)
 
 			// Arrange.
 			w := httptest.NewRecorder()
@@ -179,10 +410,79 @@ func TestRegister(t *testing.T) {
 
 			userService.AssertExpectations(t)
 			authService.AssertExpectations(t)
+			mfaService.AssertExpectations(t)
 		})
 	}
 }
 
+func TestAuthHandler_LoginThrottled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	login := func(h *handler.AuthHandler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		bodyBytes, _ := json.Marshal(dto.LoginRequestDTO{Email: "test@example.com", Password: "wrongpassword"})
+		c.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(bodyBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Login(c)
+		return w
+	}
+
+	t.Run("Rejects a locked account before checking the password", func(t *testing.T) {
+		userService := new(MockUserService)
+		userService.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.New(constants.ErrMsgUserNotFound))
+		throttle := new(MockLoginThrottleService)
+		throttle.On("Attempt", mock.Anything, "test@example.com", mock.Anything).Return(90*time.Second+time.Millisecond, nil)
+
+		w := login(handler.NewAuthHandler(userService, new(MockAuthService), new(MockMFAService), throttle, new(recordedEvents), nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "91", w.Header().Get("Retry-After"))
+		userService.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Tells how long to wait after a failure", func(t *testing.T) {
+		userService := new(MockUserService)
+		userService.On("Authenticate", mock.Anything, "test@example.com", "wrongpassword").
+			Return(nil, errors.New(constants.ErrMsgInvalidCredentials))
+		userService.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.New(constants.ErrMsgUserNotFound))
+		throttle := new(MockLoginThrottleService)
+		throttle.On("Attempt", mock.Anything, "test@example.com", mock.Anything).Return(time.Duration(0), nil)
+		throttle.On("Failure", mock.Anything, "test@example.com", mock.Anything).Return(2*time.Second, false, nil).Once()
+
+		w := login(handler.NewAuthHandler(userService, new(MockAuthService), new(MockMFAService), throttle, new(recordedEvents), nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("Records the failure and the lockout it triggers", func(t *testing.T) {
+		userService := new(MockUserService)
+		userService.On("Authenticate", mock.Anything, "test@example.com", "wrongpassword").
+			Return(nil, errors.New(constants.ErrMsgInvalidCredentials))
+		userService.On("GetByEmail", mock.Anything, "test@example.com").Return(&entity.User{ID: "user-id"}, nil)
+		throttle := new(MockLoginThrottleService)
+		throttle.On("Attempt", mock.Anything, "test@example.com", mock.Anything).Return(time.Duration(0), nil)
+		throttle.On("Failure", mock.Anything, "test@example.com", mock.Anything).Return(15*time.Minute, true, nil)
+		events := new(recordedEvents)
+
+		w := login(handler.NewAuthHandler(userService, new(MockAuthService), new(MockMFAService), throttle, events, nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Len(t, events.events, 2)
+		assert.Equal(t, entity.EventLogin, events.events[0].Type)
+		assert.Equal(t, entity.OutcomeFailure, events.events[0].Outcome)
+		assert.Equal(t, constants.ErrMsgInvalidCredentials, events.events[0].Reason)
+		assert.Equal(t, entity.EventLockout, events.events[1].Type)
+		for _, event := range events.events {
+			assert.Equal(t, "user-id", event.UserID)
+		}
+	})
+}
+
 func TestAuthHandler_Refresh(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -203,7 +503,7 @@ func TestAuthHandler_Refresh(t *testing.T) {
 					AccessToken:  "new-access-token",
 					RefreshToken: "new-refresh-token",
 				}
-				as.On("RefreshToken", "valid-refresh-token").Return(token, nil)
+				as.On("RefreshToken", mock.Anything, "valid-refresh-token", "").Return(token, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: dto.TokenResponseDTO{
@@ -217,7 +517,7 @@ func TestAuthHandler_Refresh(t *testing.T) {
 				RefreshToken: "invalid-refresh-token",
 			},
 			setupMocks: func(as *MockAuthService) {
-				as.On("RefreshToken", "invalid-refresh-token").
+				as.On("RefreshToken", mock.Anything, "invalid-refresh-token", "").
 					Return(nil, errors.New(constants.ErrMsgInvalidToken))
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -230,7 +530,7 @@ func TestAuthHandler_Refresh(t *testing.T) {
 			// Setup
 			userService := new(MockUserService)
 			authService := new(MockAuthService)
-			handler := handler.NewAuthHandler(userService, authService)
+			handler := handler.NewAuthHandler(userService, authService, new(MockMFAService), openThrottle(), new(recordedEvents), nil)
 
 			tt.setupMocks(authService)
 
@@ -260,3 +560,361 @@ func TestAuthHandler_Refresh(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	principal := &auth.Principal{UserID: "user-id", TokenType: "access"}
+	claims := &service.Claims{UserID: "user-id", Type: "access"}
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		setupMocks     func(*MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:      "Successful logout",
+			principal: principal,
+			setupMocks: func(as *MockAuthService) {
+				as.On("Logout", mock.Anything, claims).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Missing principal",
+			setupMocks:     func(as *MockAuthService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:      "Store failure",
+			principal: principal,
+			setupMocks: func(as *MockAuthService) {
+				as.On("Logout", mock.Anything, claims).Return(errors.New("store unavailable"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(MockUserService)
+			authService := new(MockAuthService)
+			handler := handler.NewAuthHandler(userService, authService, new(MockMFAService), openThrottle(), new(recordedEvents), nil)
+
+			tt.setupMocks(authService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/logout", nil)
+			if tt.principal != nil {
+				c.Set(auth.PrincipalKey, tt.principal)
+			}
+
+			handler.Logout(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			authService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_LoginMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &service.Claims{UserID: "user-id", Type: service.TokenTypeMFAPending, RegisteredClaims: jwt.RegisteredClaims{ID: "mfa-jti"}}
+	user := &entity.User{ID: "user-id", Email: "test@example.com"}
+
+	tests := []struct {
+		name           string
+		requestBody    dto.LoginMFARequestDTO
+		setupMocks     func(*MockUserService, *MockAuthService, *MockMFAService)
+		expectedStatus int
+	}{
+		{
+			name:        "Valid code",
+			requestBody: dto.LoginMFARequestDTO{MFAToken: "mfa-token", Code: "123456"},
+			setupMocks: func(us *MockUserService, as *MockAuthService, ms *MockMFAService) {
+				as.On("ParseMFAToken", mock.Anything, "mfa-token").Return(claims, nil)
+				ms.On("Verify", mock.Anything, "user-id", "123456").Return(nil)
+				as.On("Logout", mock.Anything, claims).Return(nil)
+				us.On("GetByID", mock.Anything, "user-id").Return(user, nil)
+				as.On("GenerateToken", mock.Anything, user, mock.AnythingOfType("service.SessionMeta")).
+					Return(&dto.TokenResponseDTO{AccessToken: "access-token", RefreshToken: "refresh-token"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Invalid code",
+			requestBody: dto.LoginMFARequestDTO{MFAToken: "mfa-token", Code: "000000"},
+			setupMocks: func(us *MockUserService, as *MockAuthService, ms *MockMFAService) {
+				as.On("ParseMFAToken", mock.Anything, "mfa-token").Return(claims, nil)
+				us.On("GetByID", mock.Anything, "user-id").Return(user, nil)
+				ms.On("Verify", mock.Anything, "user-id", "000000").Return(errors.New(constants.ErrMsgInvalidMFACode))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "Invalid MFA token",
+			requestBody: dto.LoginMFARequestDTO{MFAToken: "access-token", Code: "123456"},
+			setupMocks: func(us *MockUserService, as *MockAuthService, ms *MockMFAService) {
+				as.On("ParseMFAToken", mock.Anything, "access-token").Return(nil, errors.New(constants.ErrMsgInvalidToken))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(MockUserService)
+			authService := new(MockAuthService)
+			mfaService := new(MockMFAService)
+			handler := handler.NewAuthHandler(userService, authService, mfaService, openThrottle(), new(recordedEvents), nil)
+
+			tt.setupMocks(userService, authService, mfaService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			bodyBytes, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBuffer(bodyBytes))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.LoginMFA(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			userService.AssertExpectations(t)
+			authService.AssertExpectations(t)
+			mfaService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_CookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &entity.User{ID: "user-id", Email: "test@example.com"}
+	pair := &dto.TokenResponseDTO{AccessToken: "access-token", RefreshToken: "refresh-token"}
+	cookies := &handler.TokenCookies{
+		Domain:     "example.com",
+		Path:       "/",
+		SameSite:   http.SameSiteStrictMode,
+		AccessTTL:  time.Hour,
+		RefreshTTL: 2 * time.Hour,
+	}
+
+	newRouter := func(us *MockUserService, as *MockAuthService) *gin.Engine {
+		mfaService := new(MockMFAService)
+		mfaService.On("Enabled", mock.Anything, user.ID).Return(false, nil)
+
+		h := handler.NewAuthHandler(us, as, mfaService, openThrottle(), new(recordedEvents), cookies)
+		r := gin.New()
+		r.POST("/login", h.Login)
+		r.POST("/refresh", middleware.CSRF(), h.Refresh)
+		r.POST("/logout", func(c *gin.Context) { c.Set(auth.PrincipalKey, &auth.Principal{UserID: "user-id"}) }, h.Logout)
+		return r
+	}
+
+	byName := func(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+		set := map[string]*http.Cookie{}
+		for _, cookie := range w.Result().Cookies() {
+			set[cookie.Name] = cookie
+		}
+		return set
+	}
+
+	t.Run("Login sets the tokens as cookies", func(t *testing.T) {
+		userService, authService := new(MockUserService), new(MockAuthService)
+		userService.On("Authenticate", mock.Anything, user.Email, "password123").Return(user, nil)
+		authService.On("GenerateToken", mock.Anything, user, mock.AnythingOfType("service.SessionMeta")).Return(pair, nil)
+
+		body, _ := json.Marshal(dto.LoginRequestDTO{Email: user.Email, Password: "password123"})
+		w := httptest.NewRecorder()
+		newRouter(userService, authService).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "access-token")
+
+		var response dto.CookieSessionDTO
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.CSRFToken)
+
+		set := byName(w)
+		for name, value := range map[string]string{constants.AccessTokenCookie: "access-token", constants.RefreshTokenCookie: "refresh-token"} {
+			if assert.Contains(t, set, name) {
+				assert.Equal(t, value, set[name].Value)
+				assert.True(t, set[name].HttpOnly)
+				assert.True(t, set[name].Secure)
+				assert.Equal(t, http.SameSiteStrictMode, set[name].SameSite)
+				assert.Equal(t, "example.com", set[name].Domain)
+			}
+		}
+		assert.Equal(t, 3600, set[constants.AccessTokenCookie].MaxAge)
+		if assert.Contains(t, set, constants.CSRFCookie) {
+			assert.Equal(t, response.CSRFToken, set[constants.CSRFCookie].Value)
+			assert.False(t, set[constants.CSRFCookie].HttpOnly)
+		}
+	})
+
+	t.Run("Refresh reads the cookie and needs the CSRF token", func(t *testing.T) {
+		authService := new(MockAuthService)
+		authService.On("RefreshToken", mock.Anything, "refresh-token", "").Return(pair, nil).Once()
+		r := newRouter(new(MockUserService), authService)
+
+		refresh := func(csrf string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: constants.RefreshTokenCookie, Value: "refresh-token"})
+			req.AddCookie(&http.Cookie{Name: constants.CSRFCookie, Value: "csrf-token"})
+			if csrf != "" {
+				req.Header.Set(constants.CSRFHeader, csrf)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusForbidden, refresh("").Code)
+		assert.Equal(t, http.StatusForbidden, refresh("forged").Code)
+
+		w := refresh("csrf-token")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "access-token", byName(w)[constants.AccessTokenCookie].Value)
+		authService.AssertExpectations(t)
+	})
+
+	t.Run("Logout clears the cookies", func(t *testing.T) {
+		authService := new(MockAuthService)
+		authService.On("Logout", mock.Anything, mock.Anything).Return(nil)
+
+		w := httptest.NewRecorder()
+		newRouter(new(MockUserService), authService).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/logout", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		set := byName(w)
+		for _, name := range []string{constants.AccessTokenCookie, constants.RefreshTokenCookie, constants.CSRFCookie} {
+			if assert.Contains(t, set, name) {
+				assert.Negative(t, set[name].MaxAge)
+			}
+		}
+	})
+}
+
+func TestAuthHandler_Reauth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	claims := &service.Claims{UserID: "user-id", SessionID: "session-id"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	reauth := func(h *handler.AuthHandler, body dto.ReauthRequestDTO) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.POST("/reauth", func(c *gin.Context) {
+			c.Set(auth.PrincipalKey, &auth.Principal{UserID: claims.UserID, SessionID: claims.SessionID})
+		}, h.Reauth)
+
+		bodyBytes, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/reauth", bytes.NewBuffer(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	newServices := func() (*MockUserService, *MockAuthService, *MockMFAService) {
+		us := new(MockUserService)
+		us.On("GetByID", mock.Anything, "user-id").Return(&entity.User{ID: "user-id", Email: "test@example.com"}, nil)
+		return us, new(MockAuthService), new(MockMFAService)
+	}
+
+	t.Run("The password renews the access token", func(t *testing.T) {
+		us, as, ms := newServices()
+		us.On("VerifyPassword", mock.Anything, "user-id", "password123").Return(nil)
+		as.On("ReauthToken", mock.Anything, claims).Return("fresh-token", expiresAt, nil)
+		events := new(recordedEvents)
+
+		w := reauth(handler.NewAuthHandler(us, as, ms, openThrottle(), events, nil), dto.ReauthRequestDTO{Password: "password123"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response dto.ReauthResponseDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "fresh-token", response.AccessToken)
+		require.Len(t, events.events, 1)
+		assert.Equal(t, entity.EventReauth, events.events[0].Type)
+		assert.Equal(t, entity.OutcomeSuccess, events.events[0].Outcome)
+	})
+
+	t.Run("A wrong code is refused and counted", func(t *testing.T) {
+		us, as, ms := newServices()
+		ms.On("Verify", mock.Anything, "user-id", "000000").Return(errors.New(constants.ErrMsgInvalidMFACode))
+		throttle := openThrottle()
+
+		w := reauth(handler.NewAuthHandler(us, as, ms, throttle, new(recordedEvents), nil), dto.ReauthRequestDTO{Code: "000000"})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		throttle.AssertCalled(t, "Failure", mock.Anything, "test@example.com", mock.Anything)
+		as.AssertNotCalled(t, "ReauthToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("A password or a code is required", func(t *testing.T) {
+		us, as, ms := newServices()
+
+		w := reauth(handler.NewAuthHandler(us, as, ms, openThrottle(), new(recordedEvents), nil), dto.ReauthRequestDTO{})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestAuthHandler_LoginMFAThrottled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &service.Claims{UserID: "user-id", Type: service.TokenTypeMFAPending, RegisteredClaims: jwt.RegisteredClaims{ID: "mfa-jti"}}
+	user := &entity.User{ID: "user-id", Email: "test@example.com"}
+
+	loginMFA := func(h *handler.AuthHandler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		bodyBytes, _ := json.Marshal(dto.LoginMFARequestDTO{MFAToken: "mfa-token", Code: "000000"})
+		c.Request = httptest.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBuffer(bodyBytes))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.LoginMFA(c)
+		return w
+	}
+
+	newServices := func() (*MockUserService, *MockAuthService, *MockMFAService) {
+		us, as := new(MockUserService), new(MockAuthService)
+		us.On("GetByID", mock.Anything, "user-id").Return(user, nil)
+		as.On("ParseMFAToken", mock.Anything, "mfa-token").Return(claims, nil)
+		return us, as, new(MockMFAService)
+	}
+
+	t.Run("Rejects a locked account before checking the code", func(t *testing.T) {
+		us, as, ms := newServices()
+		throttle := new(MockLoginThrottleService)
+		throttle.On("Attempt", mock.Anything, "test@example.com", mock.Anything).Return(time.Minute, nil)
+
+		w := loginMFA(handler.NewAuthHandler(us, as, ms, throttle, new(recordedEvents), nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		ms.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong codes count as failed logins and burn the token", func(t *testing.T) {
+		us, as, ms := newServices()
+		ms.On("Verify", mock.Anything, "user-id", "000000").Return(errors.New(constants.ErrMsgInvalidMFACode))
+		as.On("Logout", mock.Anything, claims).Return(nil).Once()
+		throttle := new(MockLoginThrottleService)
+		throttle.On("Attempt", mock.Anything, "test@example.com", mock.Anything).Return(time.Duration(0), nil)
+		throttle.On("Failure", mock.Anything, "test@example.com", mock.Anything).Return(time.Duration(0), false, nil)
+		throttle.On("TokenFailure", mock.Anything, "mfa-jti").Return(3, nil)
+
+		w := loginMFA(handler.NewAuthHandler(us, as, ms, throttle, new(recordedEvents), nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		throttle.AssertExpectations(t)
+		as.AssertExpectations(t)
+		throttle.AssertNotCalled(t, "Success", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// TokenCookies keeps the tokens of browser clients in HttpOnly cookies,
+// out of reach of scripts. A nil *TokenCookies answers with the tokens in
+// the body.
+type TokenCookies struct {
+	// Domain is empty for host-only cookies
+	Domain     string
+	Path       string
+	SameSite   http.SameSite
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// writeTokens answers a login or a refresh. In cookie mode the body only
+// holds the CSRF token, to echo in the X-CSRF-Token header.
+func (t *TokenCookies) writeTokens(c *gin.Context, token *dto.TokenResponseDTO) {
+	if t == nil {
+		c.JSON(http.StatusOK, token)
+		return
+	}
+
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	csrfToken := base64.RawURLEncoding.EncodeToString(csrf)
+
+	t.set(c, constants.AccessTokenCookie, token.AccessToken, t.AccessTTL, true)
+	t.set(c, constants.RefreshTokenCookie, token.RefreshToken, t.RefreshTTL, true)
+	// Scripts of the site read it, other sites can't
+	t.set(c, constants.CSRFCookie, csrfToken, t.RefreshTTL, false)
+
+	c.JSON(http.StatusOK, dto.CookieSessionDTO{CSRFToken: csrfToken})
+}
+
+// writeAccessToken answers a re-authentication, which only renews the
+// access token.
+func (t *TokenCookies) writeAccessToken(c *gin.Context, token string, expiresAt time.Time) {
+	if t == nil {
+		c.JSON(http.StatusOK, dto.ReauthResponseDTO{AccessToken: token, ExpiresAt: expiresAt})
+		return
+	}
+
+	t.set(c, constants.AccessTokenCookie, token, t.AccessTTL, true)
+	c.JSON(http.StatusOK, dto.ReauthResponseDTO{ExpiresAt: expiresAt})
+}
+
+// refreshToken is the refresh token cookie, in cookie mode.
+func (t *TokenCookies) refreshToken(c *gin.Context) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	token, err := c.Cookie(constants.RefreshTokenCookie)
+	return token, err == nil && token != ""
+}
+
+// clear expires the cookies on logout.
+func (t *TokenCookies) clear(c *gin.Context) {
+	if t == nil {
+		return
+	}
+
+	for _, name := range []string{constants.AccessTokenCookie, constants.RefreshTokenCookie, constants.CSRFCookie} {
+		t.set(c, name, "", -1, name != constants.CSRFCookie)
+	}
+}
+
+func (t *TokenCookies) set(c *gin.Context, name, value string, ttl time.Duration, httpOnly bool) {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   t.Domain,
+		Path:     t.Path,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: t.SameSite,
+	})
+}
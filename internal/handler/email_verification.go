@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+// resendVerificationMessage is the answer whatever happened to the email.
+const resendVerificationMessage = "if the email is registered and not verified yet, a new link is on its way"
+
+type EmailVerificationHandler struct {
+	verificationService service.EmailVerificationService
+	log                 *log.Logger
+}
+
+func NewEmailVerificationHandler(vs service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: vs,
+		log:                 log.Default(),
+	}
+}
+
+// Verify Email godoc
+//
+//	@Summary		Verify an email
+//	@Description	Confirm the email of an account with the token from the link sent at signup
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	dto.VerifyEmailDTO	true	"Verification token"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO	"Invalid or expired token"
+//	@Failure		422	{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Router			/email/verify [post]
+func (h *EmailVerificationHandler) Verify(c *gin.Context) {
+	var req dto.VerifyEmailDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if err := h.verificationService.Verify(c.Request.Context(), req.Token); err != nil {
+		if err.Error() == constants.ErrMsgInvalidVerificationToken {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		h.log.Printf("EMAIL VERIFICATION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Resend Verification godoc
+//
+//	@Summary		Resend the verification email
+//	@Description	Send a new verification link, at most once a minute and five times an hour. The answer is the same whether the email is registered or not
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.ResendVerificationDTO	true	"Account email"
+//	@Success		202		{object}	dto.MessageResponseDTO		"Link sent if the account needs one"
+//	@Failure		422		{object}	dto.ErrorResponseDTO		"Validation error"
+//	@Router			/email/verify/resend [post]
+func (h *EmailVerificationHandler) Resend(c *gin.Context) {
+	var req dto.ResendVerificationDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if err := h.verificationService.Resend(c.Request.Context(), req.Email); err != nil {
+		h.log.Printf("EMAIL VERIFICATION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.MessageResponseDTO{Message: resendVerificationMessage})
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type FederationHandler struct {
+	federationService service.FederationService
+	tokenService      service.AuthService
+	mfaService        service.MFAService
+	throttle          service.LoginThrottleService
+	events            service.SecurityEventService
+	cookies           *TokenCookies
+	log               *log.Logger
+}
+
+func NewFederationHandler(fs service.FederationService, ts service.AuthService, ms service.MFAService, throttle service.LoginThrottleService, es service.SecurityEventService, cookies *TokenCookies) *FederationHandler {
+	return &FederationHandler{
+		federationService: fs,
+		tokenService:      ts,
+		mfaService:        ms,
+		throttle:          throttle,
+		events:            es,
+		cookies:           cookies,
+		log:               log.Default(),
+	}
+}
+
+// List Identity Providers godoc
+//
+//	@Summary		List identity providers
+//	@Description	Names of the identity providers users can log in with
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	dto.IdentityProvidersResponseDTO	"Providers"
+//	@Router			/login/sso [get]
+func (h *FederationHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.IdentityProvidersResponseDTO{Providers: h.federationService.Providers()})
+}
+
+// Begin Federated Login godoc
+//
+//	@Summary		Start a login at an identity provider
+//	@Description	Get the provider URL to send the browser to. Once the provider redirects back with code and state, send them with the flow token to /login/sso/finish
+//	@Tags			auth
+//	@Produce		json
+//	@Param			provider	path		string					true	"Provider name"
+//	@Success		200			{object}	dto.FederatedLoginDTO	"Provider URL and flow token"
+//	@Failure		404			{object}	dto.ErrorResponseDTO	"Unknown provider"
+//	@Router			/login/sso/{provider} [post]
+func (h *FederationHandler) BeginLogin(c *gin.Context) {
+	login, err := h.federationService.BeginLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		h.federationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, login)
+}
+
+// Finish Federated Login godoc
+//
+//	@Summary		Finish a login at an identity provider
+//	@Description	Redeem the code the provider redirected with. Unknown identities are linked by verified email, to an existing account or a new one. Returns access tokens, or an MFA token when two-factor is enabled
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.FinishFederatedLoginDTO	true	"Flow token, code and state"
+//	@Success		200		{object}	dto.TokenResponseDTO		"Successfully authenticated"
+//	@Success		202		{object}	dto.MFARequiredResponseDTO	"Second factor required"
+//	@Failure		401		{object}	dto.ErrorResponseDTO		"Invalid or expired login"
+//	@Failure		403		{object}	dto.ErrorResponseDTO		"Email not verified"
+//	@Failure		409		{object}	dto.ErrorResponseDTO		"An unverified account uses the email"
+//	@Failure		422		{object}	dto.ErrorResponseDTO		"Validation error"
+//	@Failure		429		{object}	dto.ErrorResponseDTO		"Account locked, see Retry-After"
+//	@Router			/login/sso/finish [post]
+func (h *FederationHandler) FinishLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.FinishFederatedLoginDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	user, err := h.federationService.FinishLogin(ctx, req.Flow, req.Code, req.State)
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginSSO, Outcome: entity.OutcomeFailure, Reason: err.Error(),
+		})
+		h.federationError(c, err)
+		return
+	}
+
+	// A locked account stays locked, whatever the way in
+	wait, err := h.throttle.Check(ctx, user.Email, c.ClientIP())
+	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if wait > 0 {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginSSO, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: constants.ErrMsgTooManyAttempts,
+		})
+		retryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": constants.ErrMsgTooManyAttempts})
+		return
+	}
+
+	mfaEnabled, err := h.mfaService.Enabled(ctx, user.ID)
+	if err != nil {
+		h.log.Printf("MFA SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// The provider's own factors aren't known, ours still applies
+	if mfaEnabled {
+		mfaToken, err := h.tokenService.MFAToken(user)
+		if err != nil {
+			h.log.Printf("AUTH SERVICE: %s", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, dto.MFARequiredResponseDTO{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
+
+	token, err := h.tokenService.GenerateToken(ctx, user, sessionMeta(c, req.DeviceName))
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginSSO, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: err.Error(),
+		})
+		tokenError(c, h.log, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLoginSSO, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	h.cookies.writeTokens(c, token)
+}
+
+// List Identities godoc
+//
+//	@Summary		List linked identities
+//	@Description	List the identity provider accounts linked to the current user
+//	@Tags			identities
+//	@Produce		json
+//	@Success		200	{object}	dto.IdentitiesResponseDTO	"Linked identities"
+//	@Failure		401	{object}	dto.ErrorResponseDTO		"Unauthorized"
+//	@Router			/identities [get]
+func (h *FederationHandler) List(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	identities, err := h.federationService.List(c.Request.Context(), claims.UserID)
+	if err != nil {
+		h.federationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IdentitiesResponseDTO{Identities: identities})
+}
+
+// Begin Identity Link godoc
+//
+//	@Summary		Start linking an identity
+//	@Description	Get the provider URL to send the browser to, then send the code and state it redirects with to /identities/finish. Requires a login or a /reauth within 5 minutes
+//	@Tags			identities
+//	@Produce		json
+//	@Param			provider	path		string					true	"Provider name"
+//	@Success		200			{object}	dto.FederatedLoginDTO	"Provider URL and flow token"
+//	@Failure		401			{object}	dto.ErrorResponseDTO	"Unauthorized, or reauth_required"
+//	@Failure		404			{object}	dto.ErrorResponseDTO	"Unknown provider"
+//	@Router			/identities/{provider} [post]
+func (h *FederationHandler) BeginLink(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	login, err := h.federationService.BeginLink(c.Request.Context(), c.Param("provider"), claims.UserID)
+	if err != nil {
+		h.federationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, login)
+}
+
+// Finish Identity Link godoc
+//
+//	@Summary		Finish linking an identity
+//	@Description	Redeem the code the provider redirected with and link its identity to the current user, requires a login or a /reauth within 5 minutes
+//	@Tags			identities
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.FinishFederatedLoginDTO	true	"Flow token, code and state"
+//	@Success		201		{object}	entity.Identity				"Linked identity"
+//	@Failure		401		{object}	dto.ErrorResponseDTO		"Invalid or expired login, or reauth_required"
+//	@Failure		409		{object}	dto.ErrorResponseDTO		"Identity linked to another account"
+//	@Failure		422		{object}	dto.ErrorResponseDTO		"Validation error"
+//	@Router			/identities/finish [post]
+func (h *FederationHandler) FinishLink(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.FinishFederatedLoginDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	identity, err := h.federationService.FinishLink(c.Request.Context(), claims.UserID, req.Flow, req.Code, req.State)
+	if err != nil {
+		h.federationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, identity)
+}
+
+// Unlink Identity godoc
+//
+//	@Summary		Unlink an identity
+//	@Description	Remove one of the identity provider accounts linked to the current user
+//	@Tags			identities
+//	@Produce		json
+//	@Param			id	path	string	true	"Identity ID"
+//	@Success		204
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Identity not found"
+//	@Router			/identities/{id} [delete]
+func (h *FederationHandler) Unlink(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	if err := h.federationService.Unlink(c.Request.Context(), claims.UserID, c.Param("id")); err != nil {
+		h.federationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *FederationHandler) federationError(c *gin.Context, err error) {
+	switch err.Error() {
+	case constants.ErrMsgInvalidFederatedLogin, constants.ErrMsgUserNotFound:
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidFederatedLogin})
+	case constants.ErrMsgProviderEmailNotVerified:
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case constants.ErrMsgIdentityNotLinked, constants.ErrMsgIdentityInUse, constants.ErrMsgEmailInUse:
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case constants.ErrMsgUnknownProvider, constants.ErrMsgIdentityNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	default:
+		h.log.Printf("FEDERATION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
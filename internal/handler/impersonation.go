@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+type ImpersonationHandler struct {
+	impersonationService service.ImpersonationService
+	events               service.SecurityEventService
+	log                  *log.Logger
+}
+
+func NewImpersonationHandler(is service.ImpersonationService, es service.SecurityEventService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: is,
+		events:               es,
+		log:                  log.Default(),
+	}
+}
+
+// Impersonate godoc
+//
+//	@Summary		Impersonate a user
+//	@Description	Get a 15 minute access token acting as any user, to reproduce their issues. It can't be refreshed, reach admin routes or change how the user logs in, and every request made with it is audited
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path		string							true	"User ID"
+//	@Success		200		{object}	dto.ImpersonationResponseDTO	"Impersonation token"
+//	@Failure		403		{object}	dto.ErrorResponseDTO			"Missing permission"
+//	@Failure		404		{object}	dto.ErrorResponseDTO			"User not found"
+//	@Failure		409		{object}	dto.ErrorResponseDTO			"Can't impersonate yourself"
+//	@Router			/admin/impersonate/{userId} [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	impersonation, err := h.impersonationService.Impersonate(c.Request.Context(), claims, c.Param("userId"))
+	if err != nil {
+		h.impersonationError(c, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventImpersonation, Outcome: entity.OutcomeSuccess, UserID: c.Param("userId")})
+	c.JSON(http.StatusOK, impersonation)
+}
+
+// Impersonation History godoc
+//
+//	@Summary		List requests made as a user
+//	@Description	List the latest requests made with impersonation tokens of a user, and who made them
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path		string								true	"User ID"
+//	@Success		200		{object}	dto.ImpersonationAuditResponseDTO	"Audited requests"
+//	@Failure		403		{object}	dto.ErrorResponseDTO				"Missing permission"
+//	@Router			/admin/users/{userId}/impersonations [get]
+func (h *ImpersonationHandler) History(c *gin.Context) {
+	entries, err := h.impersonationService.History(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		h.impersonationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ImpersonationAuditResponseDTO{Entries: entries})
+}
+
+func (h *ImpersonationHandler) impersonationError(c *gin.Context, err error) {
+	switch err.Error() {
+	case constants.ErrMsgUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case constants.ErrMsgImpersonating:
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case constants.ErrMsgCannotImpersonateSelf:
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		h.log.Printf("IMPERSONATION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+)
+
+type KeysHandler struct {
+	signer service.Signer
+}
+
+func NewKeysHandler(signer service.Signer) *KeysHandler {
+	return &KeysHandler{
+		signer: signer,
+	}
+}
+
+// JWKS godoc
+//
+//	@Summary		Public signing keys
+//	@Description	JSON Web Key Set used to verify access tokens
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	jwks.Set	"Key set"
+//	@Router			/.well-known/jwks.json [get]
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.signer.JWKS())
+}
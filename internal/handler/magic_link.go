@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+// magicLinkMessage is the answer whether the email is registered or not.
+const magicLinkMessage = "if the email is registered, a login link is on its way"
+
+type MagicLinkHandler struct {
+	linkService  service.MagicLinkService
+	tokenService service.AuthService
+	mfaService   service.MFAService
+	throttle     service.LoginThrottleService
+	events       service.SecurityEventService
+	cookies      *TokenCookies
+	log          *log.Logger
+}
+
+func NewMagicLinkHandler(ls service.MagicLinkService, ts service.AuthService, ms service.MFAService, throttle service.LoginThrottleService, es service.SecurityEventService, cookies *TokenCookies) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		linkService:  ls,
+		tokenService: ts,
+		mfaService:   ms,
+		throttle:     throttle,
+		events:       es,
+		cookies:      cookies,
+		log:          log.Default(),
+	}
+}
+
+// Send Login Link godoc
+//
+//	@Summary		Ask for a login link
+//	@Description	Email a short-lived, single-use login link, the answer is the same whether the email is registered or not
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.MagicLinkRequestDTO	true	"Account email"
+//	@Success		202		{object}	dto.MessageResponseDTO	"Link sent if the account exists"
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Failure		429		{object}	dto.ErrorResponseDTO	"Too many failed attempts or links, see Retry-After"
+//	@Router			/login/link [post]
+func (h *MagicLinkHandler) Send(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.MagicLinkRequestDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if wait, err := h.throttle.Check(ctx, req.Email, c.ClientIP()); !h.allowed(c, wait, err) {
+		return
+	}
+
+	// Counted whether the email is registered or not, the answer can't tell
+	if wait, err := h.throttle.SendAttempt(ctx, req.Email); !h.allowed(c, wait, err) {
+		return
+	}
+
+	if err := h.linkService.Send(ctx, req.Email, c.Request.UserAgent()); err != nil {
+		h.log.Printf("MAGIC LINK SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.MessageResponseDTO{Message: magicLinkMessage})
+}
+
+// Consume Login Link godoc
+//
+//	@Summary		Log in with a login link
+//	@Description	Exchange the token of a login link for access tokens, or an MFA token when two-factor is enabled
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.ConsumeMagicLinkDTO		true	"Link token"
+//	@Success		200		{object}	dto.TokenResponseDTO		"Successfully authenticated"
+//	@Success		202		{object}	dto.MFARequiredResponseDTO	"Second factor required"
+//	@Failure		401		{object}	dto.ErrorResponseDTO		"Invalid or expired link"
+//	@Failure		403		{object}	dto.ErrorResponseDTO		"Email not verified"
+//	@Failure		422		{object}	dto.ErrorResponseDTO		"Validation error"
+//	@Failure		429		{object}	dto.ErrorResponseDTO		"Too many failed attempts, see Retry-After"
+//	@Router			/login/link/consume [post]
+func (h *MagicLinkHandler) Consume(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ConsumeMagicLinkDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	// The account isn't known before the link is read, only the IP counts
+	if wait, err := h.throttle.Attempt(ctx, "", c.ClientIP()); !h.allowed(c, wait, err) {
+		return
+	}
+
+	user, err := h.linkService.Peek(ctx, req.Token, c.Request.UserAgent())
+	if err == nil {
+		// A locked account stays locked, whatever the way in, and the
+		// link stays usable once the lock is over
+		if wait, err := h.throttle.Check(ctx, user.Email, c.ClientIP()); !h.allowed(c, wait, err) {
+			recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+				Type: entity.EventLoginLink, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: constants.ErrMsgTooManyAttempts,
+			})
+			return
+		}
+
+		user, err = h.linkService.Consume(ctx, req.Token, c.Request.UserAgent())
+	}
+	if err != nil {
+		if err.Error() != constants.ErrMsgInvalidMagicLink {
+			h.log.Printf("MAGIC LINK SERVICE: %s", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginLink, Outcome: entity.OutcomeFailure, Reason: err.Error(),
+		})
+
+		wait, _, throttleErr := h.throttle.Failure(ctx, "", c.ClientIP())
+		if throttleErr != nil {
+			h.log.Printf("LOGIN THROTTLE: %s", throttleErr.Error())
+		}
+		if wait > 0 {
+			retryAfter(c, wait)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	mfaEnabled, err := h.mfaService.Enabled(ctx, user.ID)
+	if err != nil {
+		h.log.Printf("MFA SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// The link only proves the inbox, it doesn't replace the second factor
+	if mfaEnabled {
+		if err := h.throttle.Forgive(ctx, "", c.ClientIP()); err != nil {
+			h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		}
+
+		mfaToken, err := h.tokenService.MFAToken(user)
+		if err != nil {
+			h.log.Printf("AUTH SERVICE: %s", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, dto.MFARequiredResponseDTO{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
+
+	if err := h.throttle.Success(ctx, user.Email, c.ClientIP()); err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+	}
+
+	token, err := h.tokenService.GenerateToken(ctx, user, sessionMeta(c, req.DeviceName))
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginLink, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: err.Error(),
+		})
+		tokenError(c, h.log, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLoginLink, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	h.cookies.writeTokens(c, token)
+}
+
+// allowed answers 429 when the login throttle asks to wait.
+func (h *MagicLinkHandler) allowed(c *gin.Context, wait time.Duration, err error) bool {
+	if err != nil {
+		h.log.Printf("LOGIN THROTTLE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return false
+	}
+
+	if wait > 0 {
+		retryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": constants.ErrMsgTooManyAttempts})
+		return false
+	}
+
+	return true
+}
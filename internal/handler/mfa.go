@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type MFAHandler struct {
+	userService service.UserService
+	mfaService  service.MFAService
+	log         *log.Logger
+}
+
+func NewMFAHandler(us service.UserService, ms service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		userService: us,
+		mfaService:  ms,
+		log:         log.Default(),
+	}
+}
+
+// Enroll TOTP godoc
+//
+//	@Summary		Start TOTP enrollment
+//	@Description	Generate a TOTP secret for the current user, confirm it with /mfa/totp/confirm
+//	@Tags			mfa
+//	@Produce		json
+//	@Success		200	{object}	dto.TOTPEnrollmentDTO	"Secret and otpauth URI"
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		409	{object}	dto.ErrorResponseDTO	"Already enabled"
+//	@Router			/mfa/totp/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	user, err := h.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	enrollment, err := h.mfaService.EnrollTOTP(ctx, user)
+	if err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm TOTP godoc
+//
+//	@Summary		Confirm TOTP enrollment
+//	@Description	Enable two-factor with a first code and get the recovery codes
+//	@Tags			mfa
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.MFACodeRequestDTO			true	"First TOTP code"
+//	@Success		200		{object}	dto.RecoveryCodesResponseDTO	"Recovery codes, shown once"
+//	@Failure		400		{object}	dto.ErrorResponseDTO			"Invalid code"
+//	@Failure		409		{object}	dto.ErrorResponseDTO			"Already enabled"
+//	@Router			/mfa/totp/confirm [post]
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.MFACodeRequestDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmTOTP(c.Request.Context(), claims.UserID, req.Code)
+	if err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponseDTO{RecoveryCodes: codes})
+}
+
+// Disable MFA godoc
+//
+//	@Summary		Disable two-factor
+//	@Description	Remove the TOTP enrollment and recovery codes, requires the password and a login or a /reauth within 5 minutes
+//	@Tags			mfa
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	dto.DisableMFARequestDTO	true	"Current password"
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Invalid password, or reauth_required"
+//	@Router			/mfa/totp/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.DisableMFARequestDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if err := h.mfaService.Disable(c.Request.Context(), claims.UserID, req.Password); err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MFAHandler) mfaError(c *gin.Context, err error) {
+	switch err.Error() {
+	case constants.ErrMsgInvalidCredentials:
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+	case constants.ErrMsgInvalidMFACode, constants.ErrMsgMFANotEnrolled:
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case constants.ErrMsgMFAAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		h.log.Printf("MFA SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+type OAuthHandler struct {
+	clientService        service.OAuthClientService
+	oauthService         service.OAuthService
+	introspectionService service.IntrospectionService
+	events               service.SecurityEventService
+	// consentURL is the frontend page where the user logs in and approves
+	// authorization requests
+	consentURL string
+	log        *log.Logger
+}
+
+func NewOAuthHandler(cs service.OAuthClientService, oas service.OAuthService, is service.IntrospectionService, es service.SecurityEventService, consentURL string) *OAuthHandler {
+	return &OAuthHandler{
+		clientService:        cs,
+		oauthService:         oas,
+		introspectionService: is,
+		events:               es,
+		consentURL:           consentURL,
+		log:                  log.Default(),
+	}
+}
+
+// Authorize godoc
+//
+//	@Summary		Start an authorization request
+//	@Description	Check an OAuth authorization request (authorization code with PKCE S256) and send the browser to the consent page with the same query. Errors about the client or redirect URI are answered here, others go back to the redirect URI.
+//	@Tags			oauth
+//	@Produce		json
+//	@Param			response_type			query	string	true	"Must be code"
+//	@Param			client_id				query	string	true	"Client ID"
+//	@Param			redirect_uri			query	string	true	"One of the client's redirect URIs"
+//	@Param			scope					query	string	false	"Space separated scopes"
+//	@Param			state					query	string	false	"Returned as is"
+//	@Param			code_challenge			query	string	true	"PKCE challenge"
+//	@Param			code_challenge_method	query	string	true	"Must be S256"
+//	@Param			nonce					query	string	false	"Repeated in the ID token"
+//	@Success		302
+//	@Failure		400	{object}	dto.OAuthErrorDTO	"Unknown client or redirect URI"
+//	@Router			/oauth/authorize [get]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	var req dto.AuthorizeRequestDTO
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.OAuthErrorDTO{Error: "invalid_request", ErrorDescription: constants.ErrMsgInvalidRedirectURI})
+		return
+	}
+
+	client, err := h.oauthService.ValidateAuthorize(c.Request.Context(), req)
+	if err != nil {
+		if client == nil {
+			h.oauthError(c, err)
+			return
+		}
+
+		c.Redirect(http.StatusFound, service.AuthorizeRedirect(req.RedirectURI, req.State,
+			url.Values{"error": {service.OAuthErrorCode(err)}, "error_description": {err.Error()}}))
+		return
+	}
+
+	c.Redirect(http.StatusFound, h.consentURL+"?"+c.Request.URL.RawQuery)
+}
+
+// Approve godoc
+//
+//	@Summary		Answer an authorization request
+//	@Description	Called by the consent page once the user approved, or denied, an authorization request. Returns where to send the browser, with the code or the error.
+//	@Tags			oauth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.AuthorizeRequestDTO		true	"Query of the authorization request, and the user's answer"
+//	@Success		200		{object}	dto.AuthorizeResponseDTO	"Redirect URI for the browser"
+//	@Failure		400		{object}	dto.OAuthErrorDTO			"Unknown client or redirect URI"
+//	@Failure		401		{object}	dto.ErrorResponseDTO		"Unauthorized"
+//	@Router			/oauth/authorize [post]
+func (h *OAuthHandler) Approve(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.AuthorizeRequestDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.OAuthErrorDTO{Error: "invalid_request", ErrorDescription: constants.ErrMsgInvalidRedirectURI})
+		return
+	}
+
+	redirectURI, err := h.oauthService.Authorize(c.Request.Context(), claims, req)
+	if err != nil {
+		h.oauthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AuthorizeResponseDTO{RedirectURI: redirectURI})
+}
+
+// Token godoc
+//
+//	@Summary		Issue tokens to an OAuth client
+//	@Description	Token endpoint for the authorization_code (with PKCE), refresh_token and client_credentials grants. Confidential clients authenticate through HTTP Basic or the form, public clients only send client_id.
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			grant_type		formData	string						true	"authorization_code, refresh_token or client_credentials"
+//	@Param			code			formData	string						false	"Authorization code"
+//	@Param			redirect_uri	formData	string						false	"Redirect URI of the authorization request"
+//	@Param			code_verifier	formData	string						false	"PKCE verifier"
+//	@Param			refresh_token	formData	string						false	"Refresh token"
+//	@Param			scope			formData	string						false	"Scopes for client_credentials"
+//	@Success		200				{object}	dto.OAuthTokenResponseDTO	"Tokens"
+//	@Failure		400				{object}	dto.OAuthErrorDTO			"Invalid grant or request"
+//	@Failure		401				{object}	dto.OAuthErrorDTO			"Invalid client credentials"
+//	@Router			/oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	var req dto.TokenRequestDTO
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.OAuthErrorDTO{Error: "invalid_request", ErrorDescription: "grant_type is required"})
+		return
+	}
+
+	token, err := h.oauthService.Token(c.Request.Context(), client, req, sessionMeta(c, ""))
+	if err != nil {
+		if err.Error() == constants.ErrMsgTokenReused {
+			recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+				Type: entity.EventTokenReuse, Outcome: entity.OutcomeFailure, UserID: refreshSubject(req.RefreshToken), Reason: err.Error(),
+			})
+			// The client only learns the grant is no good
+			err = errors.New(constants.ErrMsgInvalidGrant)
+		}
+		h.oauthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, token)
+}
+
+// Introspect Token godoc
+//
+//	@Summary		Introspect a token
+//	@Description	Tell whether an access token, refresh token or API key is active, honoring revocations and sessions (RFC 7662). Authenticated with client credentials, through HTTP Basic or the form. Only tokens issued to the calling client are reported active, unless it was registered with the introspect scope.
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token			formData	string							true	"Token to introspect"
+//	@Param			token_type_hint	formData	string							false	"Ignored, every kind of token is tried"
+//	@Success		200				{object}	dto.IntrospectionResponseDTO	"Token state, only active when it isn't"
+//	@Failure		400				{object}	dto.OAuthErrorDTO				"Missing token"
+//	@Failure		401				{object}	dto.OAuthErrorDTO				"Invalid client credentials"
+//	@Router			/oauth/introspect [post]
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	// Anybody can claim to be a public client
+	if client.Public {
+		c.JSON(http.StatusUnauthorized, dto.OAuthErrorDTO{Error: "invalid_client", ErrorDescription: constants.ErrMsgUnauthorizedClient})
+		return
+	}
+
+	var req dto.IntrospectRequestDTO
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.OAuthErrorDTO{Error: "invalid_request", ErrorDescription: "token is required"})
+		return
+	}
+
+	response, err := h.introspectionService.Introspect(ctx, client, req.Token)
+	if err != nil {
+		h.log.Printf("INTROSPECTION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.OAuthErrorDTO{Error: "server_error"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
+
+// authenticateClient reads the client credentials from HTTP Basic, or from
+// the client_id and client_secret form fields, and answers 401 when they
+// don't match a registered client.
+func (h *OAuthHandler) authenticateClient(c *gin.Context) (*entity.OAuthClient, bool) {
+	clientID, secret, ok := c.Request.BasicAuth()
+	if ok {
+		// RFC 6749 has both form encoded before going into the header
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	client, err := h.clientService.Authenticate(c.Request.Context(), clientID, secret)
+	if err != nil {
+		if err.Error() != constants.ErrMsgInvalidClient {
+			h.log.Printf("OAUTH CLIENT SERVICE: %s", err.Error())
+			c.JSON(http.StatusInternalServerError, dto.OAuthErrorDTO{Error: "server_error"})
+			return nil, false
+		}
+
+		c.Header("WWW-Authenticate", `Basic realm="golerplate"`)
+		c.JSON(http.StatusUnauthorized, dto.OAuthErrorDTO{Error: "invalid_client", ErrorDescription: err.Error()})
+		return nil, false
+	}
+
+	return client, true
+}
+
+// oauthError answers with the RFC 6749 error body, server errors are logged.
+func (h *OAuthHandler) oauthError(c *gin.Context, err error) {
+	code := service.OAuthErrorCode(err)
+
+	switch code {
+	case "server_error":
+		h.log.Printf("OAUTH SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.OAuthErrorDTO{Error: code})
+	case "invalid_client":
+		c.JSON(http.StatusUnauthorized, dto.OAuthErrorDTO{Error: code, ErrorDescription: err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, dto.OAuthErrorDTO{Error: code, ErrorDescription: err.Error()})
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type OAuthClientHandler struct {
+	clientService service.OAuthClientService
+	log           *log.Logger
+}
+
+func NewOAuthClientHandler(cs service.OAuthClientService) *OAuthClientHandler {
+	return &OAuthClientHandler{
+		clientService: cs,
+		log:           log.Default(),
+	}
+}
+
+// Create OAuth Client godoc
+//
+//	@Summary		Register an OAuth client
+//	@Description	Register an application allowed to use the OAuth endpoints, the secret of confidential clients is only shown in this response
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.CreateOAuthClientDTO	true	"Client name, type, redirect URIs and scopes"
+//	@Success		201		{object}	dto.OAuthClientCreatedDTO	"Client and its plaintext secret"
+//	@Failure		403		{object}	dto.ErrorResponseDTO		"Missing permission"
+//	@Failure		422		{object}	util.ValidationResponse		"Validation error"
+//	@Router			/admin/oauth/clients [post]
+func (h *OAuthClientHandler) Create(c *gin.Context) {
+	var req dto.CreateOAuthClientDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	created, err := h.clientService.Create(c.Request.Context(), req)
+	if err != nil {
+		if validationFailed(c, err) {
+			return
+		}
+
+		h.clientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// List OAuth Clients godoc
+//
+//	@Summary		List OAuth clients
+//	@Description	List the OAuth clients that aren't revoked
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	dto.OAuthClientsResponseDTO	"Registered clients"
+//	@Failure		403	{object}	dto.ErrorResponseDTO		"Missing permission"
+//	@Router			/admin/oauth/clients [get]
+func (h *OAuthClientHandler) List(c *gin.Context) {
+	clients, err := h.clientService.List(c.Request.Context())
+	if err != nil {
+		h.clientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.OAuthClientsResponseDTO{Clients: clients})
+}
+
+// Revoke OAuth Client godoc
+//
+//	@Summary		Revoke an OAuth client
+//	@Description	Revoke an OAuth client, its credentials stop working at once
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id	path	string	true	"Client ID"
+//	@Success		204
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Missing permission"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"OAuth client not found"
+//	@Router			/admin/oauth/clients/{id} [delete]
+func (h *OAuthClientHandler) Revoke(c *gin.Context) {
+	if err := h.clientService.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		h.clientError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OAuthClientHandler) clientError(c *gin.Context, err error) {
+	if err.Error() == constants.ErrMsgOAuthClientNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("OAUTH CLIENT SERVICE: %s", err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+type OIDCHandler struct {
+	oidcService service.OIDCService
+	log         *log.Logger
+}
+
+func NewOIDCHandler(os service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: os,
+		log:         log.Default(),
+	}
+}
+
+// Discovery godoc
+//
+//	@Summary		OpenID Provider metadata
+//	@Description	OpenID Connect discovery document, stock OIDC clients configure themselves from it
+//	@Tags			oauth
+//	@Produce		json
+//	@Success		200	{object}	dto.OIDCDiscoveryDTO	"Provider metadata"
+//	@Router			/.well-known/openid-configuration [get]
+func (h *OIDCHandler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.oidcService.Discovery())
+}
+
+// UserInfo godoc
+//
+//	@Summary		Claims of the user behind a token
+//	@Description	OpenID Connect userinfo endpoint. The token must have been granted the openid scope, profile adds name and email adds email and email_verified.
+//	@Tags			oauth
+//	@Produce		json
+//	@Success		200	{object}	dto.UserInfoDTO			"User claims"
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Missing openid scope"
+//	@Failure		500	{object}	dto.ErrorResponseDTO	"Internal server error"
+//	@Router			/oauth/userinfo [get]
+//	@Router			/oauth/userinfo [post]
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	info, err := h.oidcService.UserInfo(c.Request.Context(), claims)
+	if err != nil {
+		switch err.Error() {
+		case constants.ErrMsgOpenIDScopeRequired:
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		case constants.ErrMsgUserNotFound:
+			c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		default:
+			h.log.Printf("OIDC SERVICE: %s", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, info)
+}
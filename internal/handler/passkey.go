@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type PasskeyHandler struct {
+	userService    service.UserService
+	tokenService   service.AuthService
+	passkeyService service.PasskeyService
+	events         service.SecurityEventService
+	cookies        *TokenCookies
+	log            *log.Logger
+}
+
+func NewPasskeyHandler(us service.UserService, ts service.AuthService, ps service.PasskeyService, es service.SecurityEventService, cookies *TokenCookies) *PasskeyHandler {
+	return &PasskeyHandler{
+		userService:    us,
+		tokenService:   ts,
+		passkeyService: ps,
+		events:         es,
+		cookies:        cookies,
+		log:            log.Default(),
+	}
+}
+
+// Begin Passkey Registration godoc
+//
+//	@Summary		Start passkey registration
+//	@Description	Get the options for navigator.credentials.create, send the result to /passkeys/register/finish. Requires a login or a /reauth within 5 minutes
+//	@Tags			passkeys
+//	@Produce		json
+//	@Success		200	{object}	dto.PasskeyRegistrationDTO	"Creation options and ceremony token"
+//	@Failure		401	{object}	dto.ErrorResponseDTO		"Unauthorized, or reauth_required"
+//	@Router			/passkeys/register/begin [post]
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	user, err := h.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	registration, err := h.passkeyService.BeginRegistration(ctx, user)
+	if err != nil {
+		h.passkeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, registration)
+}
+
+// Finish Passkey Registration godoc
+//
+//	@Summary		Finish passkey registration
+//	@Description	Verify the new credential and store it for the current user, requires a login or a /reauth within 5 minutes
+//	@Tags			passkeys
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.FinishPasskeyRegistrationDTO	true	"Ceremony token and credential"
+//	@Success		201		{object}	entity.WebAuthnCredential			"Registered passkey"
+//	@Failure		400		{object}	dto.ErrorResponseDTO				"Invalid passkey"
+//	@Failure		401		{object}	dto.ErrorResponseDTO				"reauth_required"
+//	@Failure		422		{object}	dto.ErrorResponseDTO				"Validation error"
+//	@Router			/passkeys/register/finish [post]
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.FinishPasskeyRegistrationDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	user, err := h.userService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	passkey, err := h.passkeyService.FinishRegistration(ctx, user, req.Ceremony, req.Name, req.Credential)
+	if err != nil {
+		h.passkeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, passkey)
+}
+
+// List Passkeys godoc
+//
+//	@Summary		List passkeys
+//	@Description	List the passkeys registered by the current user
+//	@Tags			passkeys
+//	@Produce		json
+//	@Success		200	{object}	dto.PasskeysResponseDTO	"Registered passkeys"
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Router			/passkeys [get]
+func (h *PasskeyHandler) List(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	passkeys, err := h.passkeyService.List(c.Request.Context(), claims.UserID)
+	if err != nil {
+		h.passkeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PasskeysResponseDTO{Passkeys: passkeys})
+}
+
+// Delete Passkey godoc
+//
+//	@Summary		Delete a passkey
+//	@Description	Remove one of the current user's passkeys, requires a login or a /reauth within 5 minutes
+//	@Tags			passkeys
+//	@Produce		json
+//	@Param			id	path	string	true	"Passkey ID"
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"reauth_required"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Passkey not found"
+//	@Router			/passkeys/{id} [delete]
+func (h *PasskeyHandler) Delete(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	if err := h.passkeyService.Delete(c.Request.Context(), claims.UserID, c.Param("id")); err != nil {
+		h.passkeyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Begin Passkey Login godoc
+//
+//	@Summary		Start a passkey login
+//	@Description	Get the options for navigator.credentials.get, send the result to /login/passkey/finish
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	dto.PasskeyLoginDTO	"Request options and ceremony token"
+//	@Router			/login/passkey/begin [post]
+func (h *PasskeyHandler) BeginLogin(c *gin.Context) {
+	login, err := h.passkeyService.BeginLogin(c.Request.Context())
+	if err != nil {
+		h.passkeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, login)
+}
+
+// Finish Passkey Login godoc
+//
+//	@Summary		Finish a passkey login
+//	@Description	Verify the passkey assertion and return access tokens
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.FinishPasskeyLoginDTO	true	"Ceremony token and assertion"
+//	@Success		200		{object}	dto.TokenResponseDTO		"Successfully authenticated"
+//	@Failure		401		{object}	dto.ErrorResponseDTO		"Invalid passkey"
+//	@Failure		403		{object}	dto.ErrorResponseDTO		"Email not verified"
+//	@Failure		422		{object}	dto.ErrorResponseDTO		"Validation error"
+//	@Router			/login/passkey/finish [post]
+func (h *PasskeyHandler) FinishLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.FinishPasskeyLoginDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	user, err := h.passkeyService.FinishLogin(ctx, req.Ceremony, req.Credential)
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginPasskey, Outcome: entity.OutcomeFailure, Reason: err.Error(),
+		})
+
+		switch err.Error() {
+		case constants.ErrMsgInvalidPasskey, constants.ErrMsgInvalidPasskeySession:
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		default:
+			h.passkeyError(c, err)
+		}
+		return
+	}
+
+	// A user verifying passkey is already two factors, no MFA step here
+	token, err := h.tokenService.GenerateToken(ctx, user, sessionMeta(c, req.DeviceName))
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventLoginPasskey, Outcome: entity.OutcomeFailure, UserID: user.ID, Reason: err.Error(),
+		})
+		tokenError(c, h.log, err)
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventLoginPasskey, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+	h.cookies.writeTokens(c, token)
+}
+
+func (h *PasskeyHandler) passkeyError(c *gin.Context, err error) {
+	switch err.Error() {
+	case constants.ErrMsgInvalidPasskey, constants.ErrMsgInvalidPasskeySession:
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case constants.ErrMsgPasskeyNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	default:
+		h.log.Printf("PASSKEY SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+// forgotPasswordMessage is the answer whether the email is registered or not.
+const forgotPasswordMessage = "if the email is registered, a reset link is on its way"
+
+type PasswordHandler struct {
+	passwordResetService service.PasswordResetService
+	userService          service.UserService
+	tokenService         service.AuthService
+	events               service.SecurityEventService
+	log                  *log.Logger
+}
+
+func NewPasswordHandler(ps service.PasswordResetService, us service.UserService, ts service.AuthService, es service.SecurityEventService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordResetService: ps,
+		userService:          us,
+		tokenService:         ts,
+		events:               es,
+		log:                  log.Default(),
+	}
+}
+
+// Forgot Password godoc
+//
+//	@Summary		Ask for a password reset
+//	@Description	Email a single-use reset link, the answer is the same whether the email is registered or not
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dto.ForgotPasswordDTO	true	"Account email"
+//	@Success		202		{object}	dto.MessageResponseDTO	"Reset link sent if the account exists"
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Router			/password/forgot [post]
+func (h *PasswordHandler) Forgot(c *gin.Context) {
+	var req dto.ForgotPasswordDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if err := h.passwordResetService.Forgot(c.Request.Context(), req.Email); err != nil {
+		h.log.Printf("PASSWORD RESET SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.MessageResponseDTO{Message: forgotPasswordMessage})
+}
+
+// Reset Password godoc
+//
+//	@Summary		Reset the password
+//	@Description	Set a new password with the token from the reset link, every session is signed out
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	dto.ResetPasswordDTO	true	"Reset token and new password"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO	"Invalid or expired token"
+//	@Failure		422	{object}	util.ValidationResponse	"Validation error or password policy violation"
+//	@Router			/password/reset [post]
+func (h *PasswordHandler) Reset(c *gin.Context) {
+	var req dto.ResetPasswordDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	userID, err := h.passwordResetService.Reset(c.Request.Context(), req.Token, req.Password)
+	if err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventPasswordReset, Outcome: entity.OutcomeFailure, UserID: userID, Reason: err.Error(),
+		})
+
+		if err.Error() == constants.ErrMsgInvalidResetToken {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		if validationFailed(c, err) {
+			return
+		}
+
+		h.log.Printf("PASSWORD RESET SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventPasswordReset, Outcome: entity.OutcomeSuccess, UserID: userID})
+	c.Status(http.StatusNoContent)
+}
+
+// Change Password godoc
+//
+//	@Summary		Change the password
+//	@Description	Set a new password, requires the current one and a login or a /reauth within 5 minutes. Every session is signed out, this one included
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	dto.ChangePasswordDTO	true	"Current and new password"
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Invalid current password, or reauth_required"
+//	@Failure		422	{object}	util.ValidationResponse	"Validation error or password policy violation"
+//	@Router			/password/change [post]
+func (h *PasswordHandler) Change(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.ChangePasswordDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if err := h.userService.VerifyPassword(ctx, claims.UserID, req.CurrentPassword); err != nil {
+		recordEvent(c, h.events, h.log, &entity.SecurityEvent{
+			Type: entity.EventPasswordChange, Outcome: entity.OutcomeFailure, UserID: claims.UserID, Reason: err.Error(),
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := h.userService.SetPassword(ctx, claims.UserID, req.NewPassword); err != nil {
+		if validationFailed(c, err) {
+			return
+		}
+
+		h.log.Printf("USER SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Whoever knew the old password may hold tokens too
+	if err := h.tokenService.LogoutAll(ctx, claims.UserID); err != nil {
+		h.log.Printf("AUTH SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventPasswordChange, Outcome: entity.OutcomeSuccess, UserID: claims.UserID})
+	c.Status(http.StatusNoContent)
+}
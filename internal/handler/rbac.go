@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type RBACHandler struct {
+	rbacService service.RBACService
+	log         *log.Logger
+}
+
+func NewRBACHandler(rs service.RBACService) *RBACHandler {
+	return &RBACHandler{
+		rbacService: rs,
+		log:         log.Default(),
+	}
+}
+
+// List Roles godoc
+//
+//	@Summary		List roles
+//	@Description	List every role with its permissions
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	dto.RolesResponseDTO	"Roles"
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Router			/admin/roles [get]
+func (h *RBACHandler) ListRoles(c *gin.Context) {
+	roles, err := h.rbacService.ListRoles(c.Request.Context())
+	if err != nil {
+		h.rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RolesResponseDTO{Roles: roles})
+}
+
+// List User Roles godoc
+//
+//	@Summary		List a user's roles
+//	@Description	List the roles granted to any user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path		string						true	"User ID"
+//	@Success		200		{object}	dto.UserRolesResponseDTO	"Role names"
+//	@Failure		403		{object}	dto.ErrorResponseDTO		"Forbidden"
+//	@Router			/admin/users/{userId}/roles [get]
+func (h *RBACHandler) UserRoles(c *gin.Context) {
+	roles, err := h.rbacService.UserRoles(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		h.rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.UserRolesResponseDTO{Roles: roles})
+}
+
+// Assign Role godoc
+//
+//	@Summary		Grant a role
+//	@Description	Grant a role to any user, it shows in their next access token
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path	string				true	"User ID"
+//	@Param			request	body	dto.AssignRoleDTO	true	"Role name"
+//	@Success		204
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Role not found"
+//	@Router			/admin/users/{userId}/roles [post]
+func (h *RBACHandler) AssignRole(c *gin.Context) {
+	var req dto.AssignRoleDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	if err := h.rbacService.AssignRole(c.Request.Context(), c.Param("userId"), req.Role); err != nil {
+		h.rbacError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Remove Role godoc
+//
+//	@Summary		Remove a role
+//	@Description	Take a role away from any user, it is gone from their next access token
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path	string	true	"User ID"
+//	@Param			role	path	string	true	"Role name"
+//	@Success		204
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Role not found"
+//	@Router			/admin/users/{userId}/roles/{role} [delete]
+func (h *RBACHandler) RemoveRole(c *gin.Context) {
+	if err := h.rbacService.RemoveRole(c.Request.Context(), c.Param("userId"), c.Param("role")); err != nil {
+		h.rbacError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RBACHandler) rbacError(c *gin.Context, err error) {
+	if err.Error() == constants.ErrMsgRoleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("RBAC SERVICE: %s", err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
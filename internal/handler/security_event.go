@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type SecurityEventHandler struct {
+	eventService service.SecurityEventService
+	log          *log.Logger
+}
+
+func NewSecurityEventHandler(es service.SecurityEventService) *SecurityEventHandler {
+	return &SecurityEventHandler{
+		eventService: es,
+		log:          log.Default(),
+	}
+}
+
+// List Security Events godoc
+//
+//	@Summary		Query security events
+//	@Description	Query the logins, refreshes, lockouts, password changes and admin actions of every user, newest first. Pass next_cursor back as cursor for the older events
+//	@Tags			admin
+//	@Produce		json
+//	@Param			user_id		query		string						false	"User the event is about"
+//	@Param			actor_id	query		string						false	"User who made the request"
+//	@Param			type		query		string						false	"Event type, such as login or token_reuse"
+//	@Param			outcome		query		string						false	"success or failure"
+//	@Param			ip			query		string						false	"Client IP"
+//	@Param			since		query		string						false	"RFC 3339 time, inclusive"
+//	@Param			until		query		string						false	"RFC 3339 time, exclusive"
+//	@Param			cursor		query		string						false	"next_cursor of the previous page"
+//	@Param			limit		query		int							false	"Page size, 50 by default and 200 at most"
+//	@Success		200			{object}	dto.SecurityEventPageDTO	"Security events"
+//	@Failure		400			{object}	dto.ErrorResponseDTO		"Invalid cursor"
+//	@Failure		403			{object}	dto.ErrorResponseDTO		"Missing permission"
+//	@Failure		422			{object}	util.ValidationResponse		"Validation error"
+//	@Router			/admin/security-events [get]
+func (h *SecurityEventHandler) List(c *gin.Context) {
+	var query dto.SecurityEventQueryDTO
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	page, err := h.eventService.List(c.Request.Context(), query)
+	if err != nil {
+		h.eventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// My Security Events godoc
+//
+//	@Summary		List my security events
+//	@Description	List the logins, refreshes, lockouts and password changes of the current user, newest first. Pass next_cursor back as cursor for the older events
+//	@Tags			auth
+//	@Produce		json
+//	@Param			type	query		string						false	"Event type, such as login or token_reuse"
+//	@Param			outcome	query		string						false	"success or failure"
+//	@Param			cursor	query		string						false	"next_cursor of the previous page"
+//	@Param			limit	query		int							false	"Page size, 50 by default and 200 at most"
+//	@Success		200		{object}	dto.SecurityEventPageDTO	"Security events"
+//	@Failure		400		{object}	dto.ErrorResponseDTO		"Invalid cursor"
+//	@Failure		401		{object}	dto.ErrorResponseDTO		"Unauthorized"
+//	@Failure		422		{object}	util.ValidationResponse		"Validation error"
+//	@Router			/me/security-events [get]
+func (h *SecurityEventHandler) Mine(c *gin.Context) {
+	principal, ok := auth.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var query dto.SecurityEventQueryDTO
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	page, err := h.eventService.ListForUser(c.Request.Context(), principal.UserID, query)
+	if err != nil {
+		h.eventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *SecurityEventHandler) eventError(c *gin.Context, err error) {
+	if err.Error() == constants.ErrMsgInvalidCursor {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("SECURITY EVENT SERVICE: %s", err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
+
+// recordEvent leaves a durable trace of the event, with the client IP and
+// user agent. A failure to store it is logged, the request goes on.
+func recordEvent(c *gin.Context, events service.SecurityEventService, logger *log.Logger, event *entity.SecurityEvent) {
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+
+	// Recorded even when the client is gone before the answer
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := events.Record(ctx, event); err != nil {
+		logger.Printf("SECURITY EVENT: failed to record %s %s (user=%s): %s", event.Type, event.Outcome, event.UserID, err.Error())
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/leonardonicola/golerplate/pkg/util"
+)
+
+type SessionHandler struct {
+	sessionService service.SessionService
+	log            *log.Logger
+}
+
+func NewSessionHandler(ss service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: ss,
+		log:            log.Default(),
+	}
+}
+
+// List Sessions godoc
+//
+//	@Summary		List sessions
+//	@Description	List the active sessions of the current user
+//	@Tags			sessions
+//	@Produce		json
+//	@Success		200	{object}	dto.SessionsResponseDTO	"Active sessions"
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"Unauthorized"
+//	@Failure		500	{object}	dto.ErrorResponseDTO	"Internal server error"
+//	@Router			/sessions [get]
+func (h *SessionHandler) List(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	sessions, err := h.sessionService.List(c.Request.Context(), claims.UserID)
+	if err != nil {
+		h.log.Printf("SESSION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == claims.SessionID
+	}
+
+	c.JSON(http.StatusOK, dto.SessionsResponseDTO{Sessions: sessions})
+}
+
+// Rename Session godoc
+//
+//	@Summary		Name a session
+//	@Description	Set the device name of one of the current user's sessions
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string					true	"Session ID"
+//	@Param			request	body	dto.RenameSessionDTO	true	"Device name"
+//	@Success		204
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Session not found"
+//	@Failure		422	{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Router			/sessions/{id} [patch]
+func (h *SessionHandler) Rename(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	var req dto.RenameSessionDTO
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, util.HandleValidationError(err))
+		return
+	}
+
+	err := h.sessionService.Rename(c.Request.Context(), claims.UserID, c.Param("id"), req.DeviceName)
+	if err != nil {
+		h.sessionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Revoke Session godoc
+//
+//	@Summary		Revoke a session
+//	@Description	End one of the current user's sessions
+//	@Tags			sessions
+//	@Produce		json
+//	@Param			id	path	string	true	"Session ID"
+//	@Success		204
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Session not found"
+//	@Router			/sessions/{id} [delete]
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": constants.ErrMsgInvalidToken})
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), claims.UserID, c.Param("id")); err != nil {
+		h.sessionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Admin List Sessions godoc
+//
+//	@Summary		List a user's sessions
+//	@Description	List the active sessions of any user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path		string					true	"User ID"
+//	@Success		200		{object}	dto.SessionsResponseDTO	"Active sessions"
+//	@Failure		403		{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Router			/admin/users/{userId}/sessions [get]
+func (h *SessionHandler) AdminList(c *gin.Context) {
+	sessions, err := h.sessionService.List(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		h.log.Printf("SESSION SERVICE: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SessionsResponseDTO{Sessions: sessions})
+}
+
+// Admin Revoke Session godoc
+//
+//	@Summary		Revoke a user's session
+//	@Description	End one session of any user, requires a login or a /reauth within 5 minutes
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path	string	true	"User ID"
+//	@Param			id		path	string	true	"Session ID"
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"reauth_required"
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Failure		404	{object}	dto.ErrorResponseDTO	"Session not found"
+//	@Router			/admin/users/{userId}/sessions/{id} [delete]
+func (h *SessionHandler) AdminRevoke(c *gin.Context) {
+	if err := h.sessionService.Revoke(c.Request.Context(), c.Param("userId"), c.Param("id")); err != nil {
+		h.sessionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Admin Revoke All Sessions godoc
+//
+//	@Summary		Revoke every session of a user
+//	@Description	End all sessions of any user, requires a login or a /reauth within 5 minutes
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userId	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		401	{object}	dto.ErrorResponseDTO	"reauth_required"
+//	@Failure		403	{object}	dto.ErrorResponseDTO	"Forbidden"
+//	@Router			/admin/users/{userId}/sessions [delete]
+func (h *SessionHandler) AdminRevokeAll(c *gin.Context) {
+	if err := h.sessionService.RevokeAll(c.Request.Context(), c.Param("userId")); err != nil {
+		h.sessionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SessionHandler) sessionError(c *gin.Context, err error) {
+	if err.Error() == constants.ErrMsgSessionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	h.log.Printf("SESSION SERVICE: %s", err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
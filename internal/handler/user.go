@@ -5,33 +5,38 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
 	"github.com/leonardonicola/golerplate/internal/domain/service"
 	"github.com/leonardonicola/golerplate/internal/dto"
 	"github.com/leonardonicola/golerplate/pkg/util"
 )
 
 type UserHandler struct {
-	userService service.UserService
-	log         *log.Logger
+	userService         service.UserService
+	verificationService service.EmailVerificationService
+	events              service.SecurityEventService
+	log                 *log.Logger
 }
 
-func NewUserHandler(us service.UserService) *UserHandler {
+func NewUserHandler(us service.UserService, vs service.EmailVerificationService, es service.SecurityEventService) *UserHandler {
 	return &UserHandler{
-		userService: us,
-		log:         log.Default(),
+		userService:         us,
+		verificationService: vs,
+		events:              es,
+		log:                 log.Default(),
 	}
 }
 
 // Register godoc
 //
 //	@Summary		Register a new user
-//	@Description	Register a new user in the system
+//	@Description	Register a new user in the system and email them a verification link
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		dto.RegisterUserDTO		true	"User registration details"
 //	@Success		201		{object}	dto.RegisterResponseDTO	"Successfully created user"
-//	@Failure		422		{object}	dto.ErrorResponseDTO	"Validation error"
+//	@Failure		422		{object}	util.ValidationResponse	"Validation error or password policy violation"
 //	@Failure		500		{object}	dto.ErrorResponseDTO	"Internal server error"
 //	@Router			/register [post]
 func (h *UserHandler) Register(c *gin.Context) {
@@ -48,10 +53,21 @@ func (h *UserHandler) Register(c *gin.Context) {
 	user, err := h.userService.Create(ctx, req)
 
 	if err != nil {
+		if validationFailed(c, err) {
+			return
+		}
+
 		h.log.Print(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
+	recordEvent(c, h.events, h.log, &entity.SecurityEvent{Type: entity.EventRegister, Outcome: entity.OutcomeSuccess, UserID: user.ID})
+
+	// The account exists either way, a new link can be asked for later
+	if err := h.verificationService.Send(ctx, user); err != nil {
+		h.log.Printf("EMAIL VERIFICATION SERVICE: %s", err.Error())
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"user": user})
 }
@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers transactional emails, swap it to change the provider.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SendInBackground sends msg without holding up the caller, who may be
+// answering a request. Failures are only logged.
+func SendInBackground(ctx context.Context, sender Sender, msg Message) {
+	go func() {
+		if err := sender.Send(context.WithoutCancel(ctx), msg); err != nil {
+			log.Printf("MAIL: %s", err.Error())
+		}
+	}()
+}
+
+type logSender struct {
+	log *log.Logger
+}
+
+// NewLogSender prints messages instead of sending them, links included, so
+// it is only meant for development.
+func NewLogSender() Sender {
+	return &logSender{
+		log: log.Default(),
+	}
+}
+
+func (s *logSender) Send(ctx context.Context, msg Message) error {
+	s.log.Printf("MAIL: to %s, %q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPSender sends through an SMTP relay, authenticating when a username
+// is given.
+func NewSMTPSender(host, port, username, password, from string) Sender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpSender{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		s.from, headerValue(msg.To), headerValue(msg.Subject), msg.Body)
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(body))
+}
+
+// headerValue keeps a value from adding headers of its own.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS roles (
+  id UUID PRIMARY KEY,
+  name VARCHAR(50) NOT NULL UNIQUE,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+  id UUID PRIMARY KEY,
+  name VARCHAR(100) NOT NULL UNIQUE,
+  description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
+
+INSERT INTO permissions (id, name, description) VALUES
+  (gen_random_uuid(), 'users:read', 'Read any user'),
+  (gen_random_uuid(), 'users:write', 'Change any user'),
+  (gen_random_uuid(), 'sessions:read', 'List the sessions of any user'),
+  (gen_random_uuid(), 'sessions:write', 'Revoke the sessions of any user'),
+  (gen_random_uuid(), 'roles:read', 'List roles and their permissions'),
+  (gen_random_uuid(), 'roles:write', 'Grant and remove roles');
+
+INSERT INTO roles (id, name, description) VALUES
+  (gen_random_uuid(), 'admin', 'Every permission');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions WHERE roles.name = 'admin';
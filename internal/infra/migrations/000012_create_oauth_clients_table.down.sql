@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name IN ('clients:read', 'clients:write');
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+  id UUID PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  secret_hash CHAR(64) NOT NULL,
+  revoked_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO permissions (id, name, description) VALUES
+  (gen_random_uuid(), 'clients:read', 'List OAuth clients'),
+  (gen_random_uuid(), 'clients:write', 'Register and revoke OAuth clients');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name IN ('clients:read', 'clients:write');
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS oauth_authorization_codes;
+ALTER TABLE oauth_clients
+  DROP COLUMN IF EXISTS scopes,
+  DROP COLUMN IF EXISTS redirect_uris,
+  DROP COLUMN IF EXISTS public;
+
+-- secret_hash stays nullable: public clients have none, and putting NOT NULL
+-- back would mean deleting them
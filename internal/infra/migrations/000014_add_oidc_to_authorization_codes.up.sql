@@ -0,0 +1,3 @@
+ALTER TABLE oauth_authorization_codes
+  ADD COLUMN IF NOT EXISTS nonce TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS auth_time TIMESTAMP;
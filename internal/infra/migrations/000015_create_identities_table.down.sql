@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS identities;
+
+-- password, cpf and age stay nullable: federated users have none, and
+-- putting NOT NULL back would mean deleting or inventing their data
@@ -0,0 +1,19 @@
+-- Users provisioned by a federated login have no password, CPF or age
+ALTER TABLE users
+  ALTER COLUMN password DROP NOT NULL,
+  ALTER COLUMN cpf DROP NOT NULL,
+  ALTER COLUMN age DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS identities (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider VARCHAR(50) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  last_login_at TIMESTAMP,
+
+  CONSTRAINT uq_identities_provider_subject UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_identities_user_id ON identities(user_id);
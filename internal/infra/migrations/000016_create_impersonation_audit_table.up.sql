@@ -0,0 +1,22 @@
+-- No foreign keys, the audit outlives the users it mentions.
+CREATE TABLE IF NOT EXISTS impersonation_audit (
+  id UUID PRIMARY KEY,
+  actor_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  token_id UUID NOT NULL,
+  method VARCHAR(10) NOT NULL,
+  path TEXT NOT NULL,
+  status SMALLINT NOT NULL,
+  ip VARCHAR(45) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_impersonation_audit_actor_id ON impersonation_audit(actor_id);
+CREATE INDEX idx_impersonation_audit_user_id ON impersonation_audit(user_id);
+
+INSERT INTO permissions (id, name, description) VALUES
+  (gen_random_uuid(), 'users:impersonate', 'Act as any user, every request is audited');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name = 'users:impersonate';
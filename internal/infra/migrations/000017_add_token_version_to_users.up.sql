@@ -0,0 +1,4 @@
+-- Bumping token_version ends every token issued to the user before.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
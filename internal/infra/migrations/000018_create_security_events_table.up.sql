@@ -0,0 +1,28 @@
+-- No foreign keys, the events outlive the users they mention.
+CREATE TABLE IF NOT EXISTS security_events (
+  id UUID PRIMARY KEY,
+  type VARCHAR(50) NOT NULL,
+  outcome VARCHAR(20) NOT NULL,
+  actor_id UUID,
+  user_id UUID,
+  ip VARCHAR(45) NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  reason TEXT NOT NULL DEFAULT '',
+  trace_id VARCHAR(32) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_security_events_created_at ON security_events(created_at DESC, id DESC);
+CREATE INDEX idx_security_events_user_id ON security_events(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_security_events_actor_id ON security_events(actor_id, created_at DESC, id DESC);
+
+-- Append-only, events are never changed or removed once written
+CREATE RULE security_events_no_update AS ON UPDATE TO security_events DO INSTEAD NOTHING;
+CREATE RULE security_events_no_delete AS ON DELETE TO security_events DO INSTEAD NOTHING;
+
+INSERT INTO permissions (id, name, description) VALUES
+  (gen_random_uuid(), 'security_events:read', 'Query the security events of every user');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name = 'security_events:read';
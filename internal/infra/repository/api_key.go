@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	// GetByHash finds a key whose user is neither disabled nor deleted.
+	GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	// ListByUser returns the keys that are neither revoked nor expired.
+	ListByUser(ctx context.Context, userID string) ([]*entity.APIKey, error)
+	// Touch records a use of the key, at most once a minute.
+	Touch(ctx context.Context, id string) error
+	Revoke(ctx context.Context, userID, id string) error
+}
+
+type apiKeyRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) APIKeyRepository {
+	return &apiKeyRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "api_keys"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt).
+		Scan(&key.CreatedAt)
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "api_keys"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	key := &entity.APIKey{}
+
+	query := `
+    SELECT k.id, k.user_id, k.name, k.prefix, k.key_hash, k.scopes, k.expires_at, k.last_used_at, k.revoked_at, k.created_at
+    FROM api_keys k
+    JOIN users u ON u.id = k.user_id
+    WHERE k.key_hash = $1 AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+  `
+
+	err := r.db.QueryRow(ctx, query, keyHash).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgAPIKeyNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID string) ([]*entity.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "api_keys"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+    FROM api_keys
+    WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+    ORDER BY created_at DESC
+  `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*entity.APIKey{}
+	for rows.Next() {
+		key := &entity.APIKey{}
+		err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&key.Scopes,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id string) error {
+	query := `
+    UPDATE api_keys
+    SET last_used_at = NOW()
+    WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+  `
+
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "api_keys"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE api_keys
+    SET revoked_at = NOW()
+    WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgAPIKeyNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *entity.EmailVerificationToken) error
+	// Consume marks an unused, unexpired token as used and returns its
+	// user, so a token only ever works once.
+	Consume(ctx context.Context, tokenHash string) (string, error)
+	// CountSince counts the tokens issued to the user after the given time.
+	CountSince(ctx context.Context, userID string, since time.Time) (int, error)
+}
+
+type emailVerificationRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewEmailVerificationRepository(db *pgxpool.Pool) EmailVerificationRepository {
+	return &emailVerificationRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *emailVerificationRepository) Create(ctx context.Context, token *entity.EmailVerificationToken) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "email_verification_tokens"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO email_verification_tokens (id, user_id, token_hash, expires_at)
+    VALUES ($1, $2, $3, $4)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.CreatedAt)
+}
+
+func (r *emailVerificationRepository) Consume(ctx context.Context, tokenHash string) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "email_verification_tokens"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	var userID string
+
+	query := `
+    UPDATE email_verification_tokens
+    SET used_at = NOW()
+    WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+    RETURNING user_id
+  `
+
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(&userID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errors.New(constants.ErrMsgInvalidVerificationToken)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func (r *emailVerificationRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "email_verification_tokens"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	var count int
+
+	query := `
+    SELECT COUNT(*)
+    FROM email_verification_tokens
+    WHERE user_id = $1 AND created_at > $2
+  `
+
+	err := r.db.QueryRow(ctx, query, userID, since).Scan(&count)
+	return count, err
+}
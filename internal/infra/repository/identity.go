@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type IdentityRepository interface {
+	// Create fails with ErrMsgIdentityInUse when the subject is already
+	// linked.
+	Create(ctx context.Context, identity *entity.Identity) error
+	GetBySubject(ctx context.Context, provider, subject string) (*entity.Identity, error)
+	ListByUser(ctx context.Context, userID string) ([]*entity.Identity, error)
+	Touch(ctx context.Context, id string) error
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type identityRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewIdentityRepository(db *pgxpool.Pool) IdentityRepository {
+	return &identityRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *entity.Identity) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "identities"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO identities (id, user_id, provider, subject, email, last_login_at)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    ON CONFLICT (provider, subject) DO NOTHING
+    RETURNING created_at
+  `
+
+	err := r.db.QueryRow(ctx, query, identity.ID, identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.LastLoginAt).
+		Scan(&identity.CreatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New(constants.ErrMsgIdentityInUse)
+	}
+
+	return err
+}
+
+func (r *identityRepository) GetBySubject(ctx context.Context, provider, subject string) (*entity.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "identities"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	identity := &entity.Identity{}
+
+	query := `
+    SELECT id, user_id, provider, subject, email, created_at, last_login_at
+    FROM identities
+    WHERE provider = $1 AND subject = $2
+  `
+
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgIdentityNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (r *identityRepository) ListByUser(ctx context.Context, userID string) ([]*entity.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "identities"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT id, user_id, provider, subject, email, created_at, last_login_at
+    FROM identities
+    WHERE user_id = $1
+    ORDER BY created_at
+  `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*entity.Identity{}
+	for rows.Next() {
+		identity := &entity.Identity{}
+		err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+			&identity.LastLoginAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *identityRepository) Touch(ctx context.Context, id string) error {
+	query := `
+    UPDATE identities
+    SET last_login_at = NOW()
+    WHERE id = $1
+  `
+
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *identityRepository) Delete(ctx context.Context, userID, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "identities"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	query := `
+    DELETE FROM identities
+    WHERE id = $1 AND user_id = $2
+  `
+
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgIdentityNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ImpersonationAuditRepository interface {
+	Create(ctx context.Context, entry *entity.ImpersonationAudit) error
+	// ListByUser returns the requests made as the user, newest first.
+	ListByUser(ctx context.Context, userID string, limit int) ([]*entity.ImpersonationAudit, error)
+}
+
+type impersonationAuditRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewImpersonationAuditRepository(db *pgxpool.Pool) ImpersonationAuditRepository {
+	return &impersonationAuditRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *impersonationAuditRepository) Create(ctx context.Context, entry *entity.ImpersonationAudit) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "impersonation_audit"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO impersonation_audit (id, actor_id, user_id, token_id, method, path, status, ip)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, entry.ID, entry.ActorID, entry.UserID, entry.TokenID, entry.Method, entry.Path, entry.Status, entry.IP).
+		Scan(&entry.CreatedAt)
+}
+
+func (r *impersonationAuditRepository) ListByUser(ctx context.Context, userID string, limit int) ([]*entity.ImpersonationAudit, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "impersonation_audit"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT id, actor_id, user_id, token_id, method, path, status, ip, created_at
+    FROM impersonation_audit
+    WHERE user_id = $1
+    ORDER BY created_at DESC
+    LIMIT $2
+  `
+
+	rows, err := r.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*entity.ImpersonationAudit{}
+	for rows.Next() {
+		entry := &entity.ImpersonationAudit{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.UserID,
+			&entry.TokenID,
+			&entry.Method,
+			&entry.Path,
+			&entry.Status,
+			&entry.IP,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
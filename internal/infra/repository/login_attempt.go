@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type LoginAttemptStore interface {
+	// Get returns nil when the key has no recent failures.
+	Get(ctx context.Context, key string) (*entity.LoginAttempts, error)
+	// RecordFailure counts a failure at now, starting over when the last
+	// one is older than window.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*entity.LoginAttempts, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Forgive takes one failure back.
+	Forgive(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+	// DeleteExpired drops unlocked keys whose last failure is before the
+	// given time.
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type loginAttemptStore struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewLoginAttemptStore(db *pgxpool.Pool) LoginAttemptStore {
+	return &loginAttemptStore{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *loginAttemptStore) Get(ctx context.Context, key string) (*entity.LoginAttempts, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "login_attempts"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	attempts := &entity.LoginAttempts{}
+
+	query := `
+    SELECT key, failures, last_failure_at, locked_until
+    FROM login_attempts
+    WHERE key = $1
+  `
+
+	err := r.db.QueryRow(ctx, query, key).Scan(
+		&attempts.Key,
+		&attempts.Failures,
+		&attempts.LastFailureAt,
+		&attempts.LockedUntil,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func (r *loginAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*entity.LoginAttempts, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "login_attempts"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	attempts := &entity.LoginAttempts{Key: key}
+
+	query := `
+    INSERT INTO login_attempts (key, failures, last_failure_at)
+    VALUES ($1, 1, $2)
+    ON CONFLICT (key) DO UPDATE
+    SET failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+      last_failure_at = EXCLUDED.last_failure_at
+    RETURNING failures, last_failure_at, locked_until
+  `
+
+	err := r.db.QueryRow(ctx, query, key, now, now.Add(-window)).Scan(
+		&attempts.Failures,
+		&attempts.LastFailureAt,
+		&attempts.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func (r *loginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "login_attempts"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	_, err := r.db.Exec(ctx, `UPDATE login_attempts SET locked_until = $2 WHERE key = $1`, key, until)
+	return err
+}
+
+func (r *loginAttemptStore) Forgive(ctx context.Context, key string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "login_attempts"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	_, err := r.db.Exec(ctx, `UPDATE login_attempts SET failures = failures - 1 WHERE key = $1 AND failures > 0`, key)
+	return err
+}
+
+func (r *loginAttemptStore) Reset(ctx context.Context, key string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "login_attempts"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	_, err := r.db.Exec(ctx, `DELETE FROM login_attempts WHERE key = $1`, key)
+	return err
+}
+
+func (r *loginAttemptStore) DeleteExpired(ctx context.Context, before time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "login_attempts"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	query := `
+    DELETE FROM login_attempts
+    WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)
+  `
+
+	_, err := r.db.Exec(ctx, query, before)
+	return err
+}
+
+// PurgeLoginAttempts forgets failures older than retention every interval,
+// until ctx is done.
+func PurgeLoginAttempts(ctx context.Context, store LoginAttemptStore, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.DeleteExpired(ctx, time.Now().Add(-retention)); err != nil {
+				log.Printf("LOGIN ATTEMPT STORE: %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type MFARepository interface {
+	GetTOTP(ctx context.Context, userID string) (*entity.TOTP, error)
+	// SaveTOTP replaces an unconfirmed enrollment, a confirmed one is kept.
+	SaveTOTP(ctx context.Context, totp *entity.TOTP) error
+	// ConfirmTOTP enables the enrollment and replaces the recovery codes.
+	ConfirmTOTP(ctx context.Context, userID string, step int64, codeHashes []string) error
+	// UseStep records a TOTP step as consumed, it reports false when the
+	// step, or a later one, was already used.
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	DeleteTOTP(ctx context.Context, userID string) error
+}
+
+type mfaRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewMFARepository(db *pgxpool.Pool) MFARepository {
+	return &mfaRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *mfaRepository) GetTOTP(ctx context.Context, userID string) (*entity.TOTP, error) {
+	totp := &entity.TOTP{}
+
+	query := `
+    SELECT user_id, secret, last_used_step, confirmed_at, created_at
+    FROM user_totp
+    WHERE user_id = $1
+  `
+
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&totp.UserID,
+		&totp.Secret,
+		&totp.LastUsedStep,
+		&totp.ConfirmedAt,
+		&totp.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgMFANotEnrolled)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return totp, nil
+}
+
+func (r *mfaRepository) SaveTOTP(ctx context.Context, totp *entity.TOTP) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "user_totp"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO user_totp (user_id, secret)
+    VALUES ($1, $2)
+    ON CONFLICT (user_id) DO UPDATE
+    SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+    WHERE user_totp.confirmed_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, totp.UserID, totp.Secret)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgMFAAlreadyEnabled)
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) ConfirmTOTP(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "user_totp"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+    UPDATE user_totp
+    SET confirmed_at = NOW(), last_used_step = $2
+    WHERE user_id = $1 AND confirmed_at IS NULL
+  `
+
+	tag, err := tx.Exec(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgMFAAlreadyEnabled)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx, `INSERT INTO recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)`, uuid.NewString(), userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := `
+    UPDATE user_totp
+    SET last_used_step = $2
+    WHERE user_id = $1 AND last_used_step < $2
+  `
+
+	tag, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "recovery_codes"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE recovery_codes
+    SET used_at = NOW()
+    WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *mfaRepository) DeleteTOTP(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "user_totp"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
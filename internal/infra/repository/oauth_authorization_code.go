@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type OAuthAuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *entity.OAuthAuthorizationCode) error
+	// Consume marks an unused, unexpired code as used and returns it, so a
+	// code only ever works once.
+	Consume(ctx context.Context, codeHash string) (*entity.OAuthAuthorizationCode, error)
+}
+
+type oauthAuthorizationCodeRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewOAuthAuthorizationCodeRepository(db *pgxpool.Pool) OAuthAuthorizationCodeRepository {
+	return &oauthAuthorizationCodeRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *oauthAuthorizationCodeRepository) Create(ctx context.Context, code *entity.OAuthAuthorizationCode) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "oauth_authorization_codes"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scope, code_challenge, nonce, auth_time, expires_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scope, code.CodeChallenge, code.Nonce, code.AuthTime, code.ExpiresAt).
+		Scan(&code.CreatedAt)
+}
+
+func (r *oauthAuthorizationCodeRepository) Consume(ctx context.Context, codeHash string) (*entity.OAuthAuthorizationCode, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "oauth_authorization_codes"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	code := &entity.OAuthAuthorizationCode{}
+
+	query := `
+    UPDATE oauth_authorization_codes
+    SET used_at = NOW()
+    WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+    RETURNING code_hash, client_id, user_id, redirect_uri, scope, code_challenge, nonce, auth_time, expires_at, used_at, created_at
+  `
+
+	err := r.db.QueryRow(ctx, query, codeHash).Scan(
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		&code.Scope,
+		&code.CodeChallenge,
+		&code.Nonce,
+		&code.AuthTime,
+		&code.ExpiresAt,
+		&code.UsedAt,
+		&code.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgInvalidGrant)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *entity.OAuthClient) error
+	GetByID(ctx context.Context, id string) (*entity.OAuthClient, error)
+	// List returns the clients that aren't revoked.
+	List(ctx context.Context) ([]*entity.OAuthClient, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type oauthClientRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewOAuthClientRepository(db *pgxpool.Pool) OAuthClientRepository {
+	return &oauthClientRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *entity.OAuthClient) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "oauth_clients"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO oauth_clients (id, name, secret_hash, public, redirect_uris, scopes)
+    VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, client.ID, client.Name, client.SecretHash, client.Public, client.RedirectURIs, client.Scopes).
+		Scan(&client.CreatedAt)
+}
+
+func (r *oauthClientRepository) GetByID(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "oauth_clients"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	client := &entity.OAuthClient{}
+
+	query := `
+    SELECT id, name, COALESCE(secret_hash, ''), public, redirect_uris, scopes, revoked_at, created_at
+    FROM oauth_clients
+    WHERE id = $1
+  `
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&client.ID,
+		&client.Name,
+		&client.SecretHash,
+		&client.Public,
+		&client.RedirectURIs,
+		&client.Scopes,
+		&client.RevokedAt,
+		&client.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgOAuthClientNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (r *oauthClientRepository) List(ctx context.Context) ([]*entity.OAuthClient, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "oauth_clients"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT id, name, public, redirect_uris, scopes, created_at
+    FROM oauth_clients
+    WHERE revoked_at IS NULL
+    ORDER BY created_at DESC
+  `
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*entity.OAuthClient{}
+	for rows.Next() {
+		client := &entity.OAuthClient{}
+		err := rows.Scan(
+			&client.ID,
+			&client.Name,
+			&client.Public,
+			&client.RedirectURIs,
+			&client.Scopes,
+			&client.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+func (r *oauthClientRepository) Revoke(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "oauth_clients"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE oauth_clients
+    SET revoked_at = NOW()
+    WHERE id = $1 AND revoked_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgOAuthClientNotFound)
+	}
+
+	return nil
+}
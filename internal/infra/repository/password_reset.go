@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *entity.PasswordResetToken) error
+	// Find returns the user of an unused, unexpired token without using it.
+	Find(ctx context.Context, tokenHash string) (string, error)
+	// Consume marks an unused, unexpired token as used and returns its
+	// user, so a token only ever works once.
+	Consume(ctx context.Context, tokenHash string) (string, error)
+	// DeleteByUser drops every token still pending for the user.
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type passwordResetRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewPasswordResetRepository(db *pgxpool.Pool) PasswordResetRepository {
+	return &passwordResetRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *entity.PasswordResetToken) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "password_reset_tokens"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+    VALUES ($1, $2, $3, $4)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.CreatedAt)
+}
+
+func (r *passwordResetRepository) Find(ctx context.Context, tokenHash string) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "password_reset_tokens"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	var userID string
+
+	query := `
+    SELECT user_id FROM password_reset_tokens
+    WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+  `
+
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(&userID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errors.New(constants.ErrMsgInvalidResetToken)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func (r *passwordResetRepository) Consume(ctx context.Context, tokenHash string) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "password_reset_tokens"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	var userID string
+
+	query := `
+    UPDATE password_reset_tokens
+    SET used_at = NOW()
+    WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+    RETURNING user_id
+  `
+
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(&userID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errors.New(constants.ErrMsgInvalidResetToken)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func (r *passwordResetRepository) DeleteByUser(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "password_reset_tokens"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	_, err := r.db.Exec(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID)
+	return err
+}
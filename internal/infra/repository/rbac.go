@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type RBACRepository interface {
+	UserRoles(ctx context.Context, userID string) ([]string, error)
+	// HasPermission reports whether any of the roles grants the permission.
+	HasPermission(ctx context.Context, roles []string, permission string) (bool, error)
+	ListRoles(ctx context.Context) ([]*entity.Role, error)
+	CountMembers(ctx context.Context, role string) (int, error)
+	AssignRole(ctx context.Context, userID, role string) error
+	RemoveRole(ctx context.Context, userID, role string) error
+}
+
+type rbacRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewRBACRepository(db *pgxpool.Pool) RBACRepository {
+	return &rbacRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *rbacRepository) UserRoles(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "user_roles"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT roles.name
+    FROM user_roles
+    JOIN roles ON roles.id = user_roles.role_id
+    WHERE user_roles.user_id = $1
+    ORDER BY roles.name
+  `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *rbacRepository) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "role_permissions"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	var allowed bool
+	query := `
+    SELECT EXISTS (
+      SELECT 1
+      FROM role_permissions
+      JOIN roles ON roles.id = role_permissions.role_id
+      JOIN permissions ON permissions.id = role_permissions.permission_id
+      WHERE roles.name = ANY($1) AND permissions.name = $2
+    )
+  `
+
+	err := r.db.QueryRow(ctx, query, roles, permission).Scan(&allowed)
+	return allowed, err
+}
+
+func (r *rbacRepository) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "roles"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT roles.id, roles.name, roles.description, roles.created_at,
+      COALESCE(array_agg(permissions.name ORDER BY permissions.name) FILTER (WHERE permissions.name IS NOT NULL), '{}')
+    FROM roles
+    LEFT JOIN role_permissions ON role_permissions.role_id = roles.id
+    LEFT JOIN permissions ON permissions.id = role_permissions.permission_id
+    GROUP BY roles.id
+    ORDER BY roles.name
+  `
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []*entity.Role{}
+	for rows.Next() {
+		role := &entity.Role{}
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt, &role.Permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *rbacRepository) CountMembers(ctx context.Context, role string) (int, error) {
+	query := `
+    SELECT COUNT(*)
+    FROM user_roles
+    JOIN roles ON roles.id = user_roles.role_id
+    WHERE roles.name = $1
+  `
+
+	var count int
+	err := r.db.QueryRow(ctx, query, role).Scan(&count)
+	return count, err
+}
+
+func (r *rbacRepository) AssignRole(ctx context.Context, userID, role string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "user_roles"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO user_roles (user_id, role_id)
+    SELECT $1, id FROM roles WHERE name = $2
+    ON CONFLICT DO NOTHING
+  `
+
+	if _, err := r.db.Exec(ctx, query, userID, role); err != nil {
+		return err
+	}
+
+	// Nothing inserted is fine when the user already had the role
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)`, role).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.New(constants.ErrMsgRoleNotFound)
+	}
+
+	return nil
+}
+
+func (r *rbacRepository) RemoveRole(ctx context.Context, userID, role string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "user_roles"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	query := `
+    DELETE FROM user_roles
+    USING roles
+    WHERE user_roles.role_id = roles.id AND user_roles.user_id = $1 AND roles.name = $2
+  `
+
+	tag, err := r.db.Exec(ctx, query, userID, role)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgRoleNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	GetByID(ctx context.Context, id string) (*entity.RefreshToken, error)
+	// MarkUsed flags the token as consumed. It reports false when the token
+	// was already used, revoked or is expired, so only one caller can win.
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeByUser(ctx context.Context, userID string) error
+	RevokeBySession(ctx context.Context, sessionID string) error
+}
+
+type refreshTokenRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "refresh_tokens"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO refresh_tokens (id, family_id, session_id, user_id, expires_at)
+    VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, token.ID, token.FamilyID, token.SessionID, token.UserID, token.ExpiresAt).Scan(&token.CreatedAt)
+}
+
+func (r *refreshTokenRepository) GetByID(ctx context.Context, id string) (*entity.RefreshToken, error) {
+	token := &entity.RefreshToken{}
+
+	query := `
+    SELECT id, family_id, COALESCE(session_id::text, ''), user_id, expires_at, used_at, revoked_at, created_at
+    FROM refresh_tokens
+    WHERE id = $1
+  `
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&token.ID,
+		&token.FamilyID,
+		&token.SessionID,
+		&token.UserID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgInvalidToken)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "refresh_tokens"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE refresh_tokens
+    SET used_at = NOW()
+    WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+  `
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "refresh_tokens"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE refresh_tokens
+    SET revoked_at = NOW()
+    WHERE family_id = $1 AND revoked_at IS NULL
+  `
+
+	_, err := r.db.Exec(ctx, query, familyID)
+	return err
+}
+
+func (r *refreshTokenRepository) RevokeByUser(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "refresh_tokens"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE refresh_tokens
+    SET revoked_at = NOW()
+    WHERE user_id = $1 AND revoked_at IS NULL
+  `
+
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
+
+func (r *refreshTokenRepository) RevokeBySession(ctx context.Context, sessionID string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "refresh_tokens"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE refresh_tokens
+    SET revoked_at = NOW()
+    WHERE session_id = $1 AND revoked_at IS NULL
+  `
+
+	_, err := r.db.Exec(ctx, query, sessionID)
+	return err
+}
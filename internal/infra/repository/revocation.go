@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RevocationStore keeps track of access tokens that must be rejected before
+// they expire. Entries only need to live as long as the tokens they cover.
+type RevocationStore interface {
+	// RevokeToken denies a single token by its jti, or every token of a
+	// session when given the session ID.
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// ConsumeToken revokes a single use token and reports whether this call
+	// did, false when it was already revoked.
+	ConsumeToken(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	// RevokeUser denies every token of the user issued before revokedAt.
+	// Tokens carry iat in whole seconds, so revokedAt is truncated to the
+	// second: a token issued in the same second as the cutoff is kept.
+	RevokeUser(ctx context.Context, userID string, revokedAt, expiresAt time.Time) error
+	// IsRevoked skips the session and user checks when their IDs are empty.
+	IsRevoked(ctx context.Context, jti, sessionID, userID string, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type revocationStore struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewRevocationStore(db *pgxpool.Pool) RevocationStore {
+	return &revocationStore{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *revocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "revoked_tokens"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO revoked_tokens (jti, expires_at)
+    VALUES ($1, $2)
+    ON CONFLICT (jti) DO NOTHING
+  `
+
+	_, err := r.db.Exec(ctx, query, jti, expiresAt)
+	return err
+}
+
+func (r *revocationStore) ConsumeToken(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "revoked_tokens"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO revoked_tokens (jti, expires_at)
+    VALUES ($1, $2)
+    ON CONFLICT (jti) DO NOTHING
+  `
+
+	tag, err := r.db.Exec(ctx, query, jti, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *revocationStore) RevokeUser(ctx context.Context, userID string, revokedAt, expiresAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "revoked_users"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO revoked_users (user_id, revoked_at, expires_at)
+    VALUES ($1, $2, $3)
+    ON CONFLICT (user_id) DO UPDATE
+    SET revoked_at = EXCLUDED.revoked_at, expires_at = EXCLUDED.expires_at
+  `
+
+	_, err := r.db.Exec(ctx, query, userID, revokedAt.Truncate(time.Second), expiresAt)
+	return err
+}
+
+func (r *revocationStore) IsRevoked(ctx context.Context, jti, sessionID, userID string, issuedAt time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "revoked_tokens"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	ids := []string{jti}
+	if sessionID != "" {
+		ids = append(ids, sessionID)
+	}
+
+	var revoked bool
+	query := `
+    SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ANY($1::uuid[]))
+      OR EXISTS (SELECT 1 FROM revoked_users WHERE user_id = NULLIF($2, '')::uuid AND revoked_at > $3)
+  `
+
+	err := r.db.QueryRow(ctx, query, ids, userID, issuedAt).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *revocationStore) DeleteExpired(ctx context.Context) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "revoked_tokens"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(ctx, `DELETE FROM revoked_users WHERE expires_at <= NOW()`)
+	return err
+}
+
+// PurgeRevocations drops revocations of tokens that expired on their own
+// every interval, until ctx is done.
+func PurgeRevocations(ctx context.Context, store RevocationStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.DeleteExpired(ctx); err != nil {
+				log.Printf("REVOCATION STORE: %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type revokedUser struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// memoryRevocationStore is only safe for a single instance, revocations are
+// lost on restart and not shared between replicas.
+type memoryRevocationStore struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[string]revokedUser
+}
+
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[string]revokedUser),
+	}
+}
+
+func (r *memoryRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[jti] = expiresAt
+	return nil
+}
+
+func (r *memoryRevocationStore) ConsumeToken(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[jti]; ok {
+		return false, nil
+	}
+
+	r.tokens[jti] = expiresAt
+	return true, nil
+}
+
+func (r *memoryRevocationStore) RevokeUser(ctx context.Context, userID string, revokedAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[userID] = revokedUser{revokedAt: revokedAt.Truncate(time.Second), expiresAt: expiresAt}
+	return nil
+}
+
+func (r *memoryRevocationStore) IsRevoked(ctx context.Context, jti, sessionID, userID string, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.tokens[jti]; ok {
+		return true, nil
+	}
+
+	if _, ok := r.tokens[sessionID]; ok && sessionID != "" {
+		return true, nil
+	}
+
+	if user, ok := r.users[userID]; ok && user.revokedAt.After(issuedAt) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *memoryRevocationStore) DeleteExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for jti, expiresAt := range r.tokens {
+		if !expiresAt.After(now) {
+			delete(r.tokens, jti)
+		}
+	}
+
+	for userID, user := range r.users {
+		if !user.expiresAt.After(now) {
+			delete(r.users, userID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *entity.SecurityEvent) error
+	// List returns the events matching the filter, newest first.
+	List(ctx context.Context, filter entity.SecurityEventFilter) ([]*entity.SecurityEvent, error)
+}
+
+type securityEventRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewSecurityEventRepository(db *pgxpool.Pool) SecurityEventRepository {
+	return &securityEventRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *securityEventRepository) Create(ctx context.Context, event *entity.SecurityEvent) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "security_events"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO security_events (id, type, outcome, actor_id, user_id, ip, user_agent, reason, trace_id)
+    VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, $6, $7, $8, $9)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query, event.ID, event.Type, event.Outcome, event.ActorID, event.UserID,
+		event.IP, event.UserAgent, event.Reason, event.TraceID).Scan(&event.CreatedAt)
+}
+
+func (r *securityEventRepository) List(ctx context.Context, filter entity.SecurityEventFilter) ([]*entity.SecurityEvent, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "security_events"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != "" {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.ActorID != "" {
+		where("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if filter.Outcome != "" {
+		where("outcome = $%d", filter.Outcome)
+	}
+	if filter.IP != "" {
+		where("ip = $%d", filter.IP)
+	}
+	if filter.Since != nil {
+		where("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		where("created_at < $%d", *filter.Until)
+	}
+	if filter.Before != nil {
+		args = append(args, filter.Before.CreatedAt, filter.Before.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `
+    SELECT id, type, outcome, COALESCE(actor_id::text, ''), COALESCE(user_id::text, ''), ip, user_agent, reason, trace_id, created_at
+    FROM security_events
+  `
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ") + "\n"
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf("ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*entity.SecurityEvent{}
+	for rows.Next() {
+		event := &entity.SecurityEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.Outcome,
+			&event.ActorID,
+			&event.UserID,
+			&event.IP,
+			&event.UserAgent,
+			&event.Reason,
+			&event.TraceID,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *entity.Session) error
+	GetByID(ctx context.Context, id string) (*entity.Session, error)
+	// ListByUser returns the sessions that are neither revoked nor expired.
+	ListByUser(ctx context.Context, userID string) ([]*entity.Session, error)
+	Touch(ctx context.Context, id string, expiresAt time.Time) error
+	Rename(ctx context.Context, userID, id, deviceName string) error
+	Revoke(ctx context.Context, userID, id string) error
+	RevokeByUser(ctx context.Context, userID string) error
+}
+
+type sessionRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewSessionRepository(db *pgxpool.Pool) SessionRepository {
+	return &sessionRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "sessions"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO sessions (id, user_id, user_agent, ip, device_name, expires_at)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    RETURNING created_at, last_used_at
+  `
+
+	return r.db.QueryRow(ctx, query, session.ID, session.UserID, session.UserAgent, session.IP, session.DeviceName, session.ExpiresAt).
+		Scan(&session.CreatedAt, &session.LastUsedAt)
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id string) (*entity.Session, error) {
+	session := &entity.Session{}
+
+	query := `
+    SELECT id, user_id, user_agent, ip, device_name, created_at, last_used_at, expires_at, revoked_at
+    FROM sessions
+    WHERE id = $1
+  `
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IP,
+		&session.DeviceName,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New(constants.ErrMsgSessionNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) ListByUser(ctx context.Context, userID string) ([]*entity.Session, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "sessions"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT id, user_id, user_agent, ip, device_name, created_at, last_used_at, expires_at
+    FROM sessions
+    WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+    ORDER BY last_used_at DESC
+  `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*entity.Session{}
+	for rows.Next() {
+		session := &entity.Session{}
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.DeviceName,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, expiresAt time.Time) error {
+	query := `
+    UPDATE sessions
+    SET last_used_at = NOW(), expires_at = $2
+    WHERE id = $1 AND revoked_at IS NULL
+  `
+
+	_, err := r.db.Exec(ctx, query, id, expiresAt)
+	return err
+}
+
+func (r *sessionRepository) Rename(ctx context.Context, userID, id, deviceName string) error {
+	query := `
+    UPDATE sessions
+    SET device_name = $3
+    WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id, userID, deviceName)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgSessionNotFound)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, userID, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "sessions"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE sessions
+    SET revoked_at = NOW()
+    WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgSessionNotFound)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeByUser(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "sessions"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE sessions
+    SET revoked_at = NOW()
+    WHERE user_id = $1 AND revoked_at IS NULL
+  `
+
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
@@ -20,6 +20,11 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*entity.User, error)
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetByCPF(ctx context.Context, cpf string) (*entity.User, error)
+	UpdatePassword(ctx context.Context, id, password string) error
+	MarkEmailVerified(ctx context.Context, id string) error
+	// BumpTokenVersion refuses the refresh tokens issued to the user so far.
+	BumpTokenVersion(ctx context.Context, id string) error
+	SetDisabled(ctx context.Context, id string, disabled bool) error
 }
 
 type userRepository struct {
@@ -55,23 +60,25 @@ func (r *userRepository) Create(ctx context.Context, user *entity.User) (*entity
 		return nil, errors.New(constants.ErrMsgEmailInUse)
 	}
 
-	// Check CPF
-	exists, err = r.cpfExists(ctx, user.CPF)
-	if err != nil {
-		return nil, err
-	}
+	// Check CPF, federated users have none
+	if user.CPF != "" {
+		exists, err = r.cpfExists(ctx, user.CPF)
+		if err != nil {
+			return nil, err
+		}
 
-	if exists {
-		return nil, errors.New(constants.ErrMsgCPFInUse)
+		if exists {
+			return nil, errors.New(constants.ErrMsgCPFInUse)
+		}
 	}
 
 	query := `
-    INSERT INTO users (id, full_name, email, cpf, age, password)
-    VALUES ($1, $2, $3, $4, $5, $6)
-    RETURNING id, full_name, email, cpf, age
+    INSERT INTO users (id, full_name, email, cpf, age, password, email_verified_at)
+    VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, 0), NULLIF($6, ''), $7)
+    RETURNING id, full_name, email, COALESCE(cpf, ''), COALESCE(age, 0)
   `
 
-	err = tx.QueryRow(ctx, query, uuid.NewString(), user.FullName, user.Email, user.CPF, user.Age, user.Password).Scan(&user.ID, &user.FullName, &user.Email, &user.CPF, &user.Age)
+	err = tx.QueryRow(ctx, query, uuid.NewString(), user.FullName, user.Email, user.CPF, int(user.Age), user.Password, user.EmailVerifiedAt).Scan(&user.ID, &user.FullName, &user.Email, &user.CPF, &user.Age)
 
 	if err != nil {
 		return nil, err
@@ -88,7 +95,8 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User,
 	user := &entity.User{}
 
 	query := `
-    SELECT id, full_name, email, cpf, age, created_at, updated_at
+    SELECT id, full_name, email, COALESCE(cpf, ''), COALESCE(age, 0), COALESCE(password, ''), created_at, updated_at, email_verified_at,
+      token_version, disabled_at
     FROM users
     WHERE id = $1 AND deleted_at IS NULL
   `
@@ -99,8 +107,12 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User,
 		&user.Email,
 		&user.CPF,
 		&user.Age,
+		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.TokenVersion,
+		&user.DisabledAt,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -118,7 +130,8 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.
 	user := &entity.User{}
 
 	query := `
-    SELECT id, full_name, email, cpf, age, password, created_at, updated_at
+    SELECT id, full_name, email, COALESCE(cpf, ''), COALESCE(age, 0), COALESCE(password, ''), created_at, updated_at, email_verified_at,
+      token_version, disabled_at
     FROM users
     WHERE email = $1 AND deleted_at IS NULL
   `
@@ -132,6 +145,9 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.
 		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.TokenVersion,
+		&user.DisabledAt,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -149,7 +165,8 @@ func (r *userRepository) GetByCPF(ctx context.Context, cpf string) (*entity.User
 	user := &entity.User{}
 
 	query := `
-    SELECT id, full_name, email, cpf, age, password, created_at, updated_at
+    SELECT id, full_name, email, COALESCE(cpf, ''), COALESCE(age, 0), COALESCE(password, ''), created_at, updated_at, email_verified_at,
+      token_version, disabled_at
     FROM users
     WHERE cpf = $1 AND deleted_at IS NULL
   `
@@ -163,6 +180,9 @@ func (r *userRepository) GetByCPF(ctx context.Context, cpf string) (*entity.User
 		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.TokenVersion,
+		&user.DisabledAt,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -176,6 +196,98 @@ func (r *userRepository) GetByCPF(ctx context.Context, cpf string) (*entity.User
 	return user, nil
 }
 
+func (r *userRepository) UpdatePassword(ctx context.Context, id, password string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "users"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE users
+    SET password = $2
+    WHERE id = $1 AND deleted_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id, password)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgUserNotFound)
+	}
+
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "users"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE users
+    SET email_verified_at = COALESCE(email_verified_at, NOW())
+    WHERE id = $1 AND deleted_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgUserNotFound)
+	}
+
+	return nil
+}
+
+func (r *userRepository) BumpTokenVersion(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "users"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE users
+    SET token_version = token_version + 1
+    WHERE id = $1 AND deleted_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgUserNotFound)
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "users"),
+		attribute.String("db.operation", "UPDATE")))
+	defer span.End()
+
+	query := `
+    UPDATE users
+    SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END
+    WHERE id = $1 AND deleted_at IS NULL
+  `
+
+	tag, err := r.db.Exec(ctx, query, id, disabled)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgUserNotFound)
+	}
+
+	return nil
+}
+
 func (r *userRepository) emailExists(ctx context.Context, email string) (bool, error) {
 	var count int
 	query := `
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type WebAuthnRepository interface {
+	Create(ctx context.Context, credential *entity.WebAuthnCredential) error
+	ListByUser(ctx context.Context, userID string) ([]*entity.WebAuthnCredential, error)
+	// UpdateSignCount records a successful assertion with the counter the
+	// authenticator reported.
+	UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, backupState bool) error
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type webAuthnRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewWebAuthnRepository(db *pgxpool.Pool) WebAuthnRepository {
+	return &webAuthnRepository{
+		db:     db,
+		tracer: otel.Tracer(constants.TRACER_NAME),
+	}
+}
+
+func (r *webAuthnRepository) Create(ctx context.Context, credential *entity.WebAuthnCredential) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "webauthn_credentials"),
+		attribute.String("db.operation", "INSERT")))
+	defer span.End()
+
+	query := `
+    INSERT INTO webauthn_credentials (id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state, name)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    RETURNING created_at
+  `
+
+	return r.db.QueryRow(ctx, query,
+		credential.ID,
+		credential.UserID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		credential.AAGUID,
+		int64(credential.SignCount),
+		credential.Transports,
+		credential.BackupEligible,
+		credential.BackupState,
+		credential.Name,
+	).Scan(&credential.CreatedAt)
+}
+
+func (r *webAuthnRepository) ListByUser(ctx context.Context, userID string) ([]*entity.WebAuthnCredential, error) {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "webauthn_credentials"),
+		attribute.String("db.operation", "SELECT")))
+	defer span.End()
+
+	query := `
+    SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state, name, created_at, last_used_at
+    FROM webauthn_credentials
+    WHERE user_id = $1
+    ORDER BY created_at
+  `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []*entity.WebAuthnCredential{}
+	for rows.Next() {
+		var signCount int64
+		credential := &entity.WebAuthnCredential{}
+		err := rows.Scan(
+			&credential.ID,
+			&credential.UserID,
+			&credential.CredentialID,
+			&credential.PublicKey,
+			&credential.AttestationType,
+			&credential.AAGUID,
+			&signCount,
+			&credential.Transports,
+			&credential.BackupEligible,
+			&credential.BackupState,
+			&credential.Name,
+			&credential.CreatedAt,
+			&credential.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		credential.SignCount = uint32(signCount)
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *webAuthnRepository) UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, backupState bool) error {
+	query := `
+    UPDATE webauthn_credentials
+    SET sign_count = $2, backup_state = $3, last_used_at = NOW()
+    WHERE credential_id = $1
+  `
+
+	_, err := r.db.Exec(ctx, query, credentialID, int64(signCount), backupState)
+	return err
+}
+
+func (r *webAuthnRepository) Delete(ctx context.Context, userID, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Query", trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.table", "webauthn_credentials"),
+		attribute.String("db.operation", "DELETE")))
+	defer span.End()
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New(constants.ErrMsgPasskeyNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+// Package sso runs the OpenID Connect authorization code flow against
+// external identity providers, so users can log in with an account they
+// already have elsewhere.
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/pkg/jwks"
+)
+
+// Config describes a provider registered for golerplate.
+type Config struct {
+	// Name identifies the provider in URLs and linked identities
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// RedirectURL is the page the provider sends the browser back to
+	RedirectURL string
+}
+
+// Claims are what a verified ID token tells about the user.
+type Claims struct {
+	Nonce         string `json:"nonce"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+type Provider interface {
+	Name() string
+	// AuthCodeURL is where the browser goes to log in at the provider, with
+	// the S256 challenge of verifier.
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	// Exchange redeems an authorization code and returns the claims of the
+	// ID token, once its signature, issuer, audience and nonce are checked.
+	Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error)
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type provider struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *jwks.Set
+}
+
+// NewProvider discovers the provider on first use, so golerplate starts
+// even when a provider is down.
+func NewProvider(config Config) Provider {
+	return &provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *provider) Name() string {
+	return p.config.Name
+}
+
+func (p *provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (p *provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("token endpoint: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint: %d %s %s", res.StatusCode, body.Error, body.ErrorDescription)
+	}
+
+	if body.IDToken == "" {
+		return nil, errors.New("token endpoint: no id_token, is the openid scope requested?")
+	}
+
+	return p.verify(ctx, meta, body.IDToken, nonce)
+}
+
+func (p *provider) verify(ctx context.Context, meta *metadata, idToken, nonce string) (*Claims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512", "EdDSA"}))
+
+	claims := &Claims{}
+	_, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (any, error) {
+		keys, err := p.keySet(ctx, meta, false)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := keys.Keyfunc(token)
+		if errors.Is(err, jwks.ErrKeyNotFound) {
+			// The provider may have rotated its keys since they were fetched
+			if keys, err = p.keySet(ctx, meta, true); err != nil {
+				return nil, err
+			}
+			return keys.Keyfunc(token)
+		}
+
+		return key, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("id_token: %w", err)
+	}
+
+	switch {
+	case claims.Issuer != meta.Issuer:
+		return nil, fmt.Errorf("id_token: unexpected issuer %q", claims.Issuer)
+	case !claims.VerifyAudience(p.config.ClientID, true):
+		return nil, errors.New("id_token: issued to another client")
+	case claims.ExpiresAt == nil:
+		return nil, errors.New("id_token: no expiry")
+	case claims.Subject == "":
+		return nil, errors.New("id_token: no subject")
+	case claims.Nonce != nonce:
+		return nil, errors.New("id_token: nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+// discover fetches the provider metadata once, its issuer must be the one
+// configured.
+func (p *provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	endpoint := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery: unexpected status %d", res.StatusCode)
+	}
+
+	meta := &metadata{}
+	if err := json.NewDecoder(res.Body).Decode(meta); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+
+	if meta.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("discovery: issuer %q doesn't match %q", meta.Issuer, p.config.Issuer)
+	}
+
+	p.metadata = meta
+	return meta, nil
+}
+
+// keySet returns the cached signing keys of the provider, fetched again when
+// refresh is set.
+func (p *provider) keySet(ctx context.Context, meta *metadata, refresh bool) (*jwks.Set, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil && !refresh {
+		return p.keys, nil
+	}
+
+	keys, err := jwks.Fetch(ctx, meta.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = keys
+	return keys, nil
+}
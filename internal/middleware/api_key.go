@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+func extractAPIKey(c *gin.Context) (string, bool) {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key, true
+	}
+
+	key, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "+service.APIKeyPrefix)
+	if !found {
+		return "", false
+	}
+
+	return service.APIKeyPrefix + key, true
+}
+
+func (m *JWTAuthMiddleware) authenticateAPIKey(c *gin.Context, key string) {
+	apiKey, err := m.apiKeyService.Authenticate(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{
+			Message: constants.ErrMsgInvalidAPIKey,
+		})
+		c.Abort()
+		return
+	}
+
+	if !apiKey.Allows(c.Request.Method) {
+		c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+			Message: constants.ErrMsgInsufficientScope,
+		})
+		c.Abort()
+		return
+	}
+
+	setPrincipal(c, &auth.Principal{
+		UserID:     apiKey.UserID,
+		Scopes:     apiKey.Scopes,
+		TokenType:  service.TokenTypeAPIKey,
+		AuthMethod: auth.MethodAPIKey,
+		TokenID:    apiKey.ID,
+	})
+	c.Set("apiKey", apiKey)
+
+	c.Next()
+}
+
+// InteractiveOnly keeps API keys and tokens issued to OAuth clients away
+// from account security routes, so a leaked key or a third party can't mint
+// more keys or change how the user logs in. It must run after
+// JWTAuthMiddleware.AuthRequired.
+func InteractiveOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c)
+
+		if !ok || principal.AuthMethod == auth.MethodAPIKey || principal.ClientID != "" {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgForbidden,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
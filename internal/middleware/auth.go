@@ -2,19 +2,21 @@ package middleware
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
 	"github.com/leonardonicola/golerplate/internal/domain/service"
 	"github.com/leonardonicola/golerplate/internal/dto"
 	"github.com/leonardonicola/golerplate/pkg/constants"
 )
 
 type JWTAuthMiddleware struct {
-	accessSecret string
+	signer        service.Signer
+	authService   service.AuthService
+	apiKeyService service.APIKeyService
 }
 
 var (
@@ -23,16 +25,26 @@ var (
 	ErrInvalidTokenType = errors.New(constants.ErrMsgInvalidTokenType)
 )
 
-func NewJWTAuthMiddleware(secret string) *JWTAuthMiddleware {
+func NewJWTAuthMiddleware(signer service.Signer, as service.AuthService, ks service.APIKeyService) *JWTAuthMiddleware {
 	return &JWTAuthMiddleware{
-		accessSecret: secret,
+		signer:        signer,
+		authService:   as,
+		apiKeyService: ks,
 	}
 }
 
+// AuthRequired accepts an access token, sent as a bearer token or in its
+// cookie, or an API key sent either as a bearer token or in the X-API-Key
+// header.
 func (m *JWTAuthMiddleware) AuthRequired() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
-		token, err := m.extractToken(c)
+		if key, ok := extractAPIKey(c); ok {
+			m.authenticateAPIKey(c, key)
+			return
+		}
+
+		token, method, err := m.extractToken(c)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{
 				Message: err.Error(),
@@ -43,6 +55,12 @@ func (m *JWTAuthMiddleware) AuthRequired() gin.HandlerFunc {
 
 		claims, err := m.validateToken(token)
 
+		// Client credentials tokens have no user to act for on this API, and
+		// ID tokens, signed with the same key, are only meant for the client
+		if err == nil && claims.Type != service.TokenTypeAccess {
+			err = ErrInvalidTokenType
+		}
+
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{
 				Message: constants.ErrMsgInvalidToken,
@@ -51,34 +69,73 @@ func (m *JWTAuthMiddleware) AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("userId", claims.UserID)
+		revoked, err := m.authService.IsRevoked(c.Request.Context(), claims)
+		if err != nil || revoked {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgInvalidToken,
+			})
+			c.Abort()
+			return
+		}
+
+		principal := &auth.Principal{
+			UserID:     claims.UserID,
+			Roles:      claims.Roles,
+			Scopes:     strings.Fields(claims.Scope),
+			SessionID:  claims.SessionID,
+			TokenType:  claims.Type,
+			AuthMethod: method,
+			ClientID:   claims.ClientID,
+			TokenID:    claims.ID,
+			FamilyID:   claims.FamilyID,
+			Unverified: claims.Unverified,
+		}
+		if claims.Act != nil {
+			principal.ActorID = claims.Act.UserID
+		}
+		if claims.AuthTime != nil {
+			principal.AuthTime = claims.AuthTime.Time
+		}
+		if claims.ExpiresAt != nil {
+			principal.ExpiresAt = claims.ExpiresAt.Time
+		}
+
+		setPrincipal(c, principal)
 
 		c.Next()
 	}
 }
 
-func (m *JWTAuthMiddleware) extractToken(c *gin.Context) (string, error) {
+// setPrincipal stores who the request is made by, in the gin context for
+// handlers and in the request context for services.
+func setPrincipal(c *gin.Context, principal *auth.Principal) {
+	c.Set(auth.PrincipalKey, principal)
+	c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+}
+
+// extractToken reads the bearer token, or the access token cookie set in
+// cookie mode, along with the auth method it was sent with.
+func (m *JWTAuthMiddleware) extractToken(c *gin.Context) (string, string, error) {
 	header := c.GetHeader("Authorization")
 
 	if header == "" {
-		return "", ErrMissingHeader
+		if token, err := c.Cookie(constants.AccessTokenCookie); err == nil && token != "" {
+			return token, auth.MethodCookie, nil
+		}
+		return "", "", ErrMissingHeader
 	}
 
 	parts := strings.Split(header, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", ErrInvalidToken
+		return "", "", ErrInvalidToken
 	}
 
-	return parts[1], nil
+	return parts[1], auth.MethodBearer, nil
 }
 
 func (m *JWTAuthMiddleware) validateToken(tokenString string) (*service.Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, service.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Method)
-		}
-		return []byte(m.accessSecret), nil
-	})
+	// A pointer, the value form never passes the assertion below
+	token, err := jwt.ParseWithClaims(tokenString, &service.Claims{}, m.signer.Keyfunc)
 
 	if err != nil {
 		return nil, err
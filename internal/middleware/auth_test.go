@@ -0,0 +1,111 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/middleware"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notRevoked only answers the revocation check AuthRequired makes.
+type notRevoked struct {
+	service.AuthService
+}
+
+func (notRevoked) IsRevoked(ctx context.Context, claims *service.Claims) (bool, error) {
+	return false, nil
+}
+
+func TestAuthRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := service.NewHMACSigner("", "access")
+	m := middleware.NewJWTAuthMiddleware(signer, notRevoked{}, nil)
+
+	sign := func(t *testing.T, claims *service.Claims) string {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+		token, err := signer.Sign(claims)
+		require.NoError(t, err)
+		return token
+	}
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, *auth.Principal) {
+		var principal *auth.Principal
+		r := gin.New()
+		r.GET("/me", m.AuthRequired(), func(c *gin.Context) {
+			fromGin, _ := auth.FromContext(c)
+			fromRequest, _ := auth.FromContext(c.Request.Context())
+			if fromGin == fromRequest {
+				principal = fromRequest
+			}
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w, principal
+	}
+
+	t.Run("Access tokens set the principal", func(t *testing.T) {
+		token := sign(t, &service.Claims{
+			UserID:           "user-id",
+			Type:             service.TokenTypeAccess,
+			Roles:            []string{"admin"},
+			SessionID:        "session-id",
+			Scope:            "openid email",
+			Unverified:       true,
+			Act:              &service.Actor{UserID: "admin-id"},
+			RegisteredClaims: jwt.RegisteredClaims{ID: "token-id"},
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w, principal := serve(req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, principal)
+		assert.Equal(t, "user-id", principal.UserID)
+		assert.True(t, principal.HasRole("admin"))
+		assert.True(t, principal.HasScope("email"))
+		assert.Equal(t, "session-id", principal.SessionID)
+		assert.Equal(t, service.TokenTypeAccess, principal.TokenType)
+		assert.Equal(t, auth.MethodBearer, principal.AuthMethod)
+		assert.True(t, principal.Impersonated())
+		assert.Equal(t, "token-id", principal.TokenID)
+		assert.True(t, principal.Unverified)
+		assert.False(t, principal.ExpiresAt.IsZero())
+	})
+
+	t.Run("Cookie tokens are told apart", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  constants.AccessTokenCookie,
+			Value: sign(t, &service.Claims{UserID: "user-id", Type: service.TokenTypeAccess}),
+		})
+		w, principal := serve(req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, principal)
+		assert.Equal(t, auth.MethodCookie, principal.AuthMethod)
+	})
+
+	t.Run("Other token types are rejected", func(t *testing.T) {
+		for _, tokenType := range []string{service.TokenTypeRefresh, service.TokenTypeClient, ""} {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+sign(t, &service.Claims{UserID: "user-id", Type: tokenType}))
+			w, principal := serve(req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, tokenType)
+			assert.Nil(t, principal)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// CSRF checks the double submit token of state-changing requests that carry
+// token cookies: the X-CSRF-Token header must match the CSRF cookie, which
+// other sites can't read. Requests with an Authorization or X-API-Key header
+// aren't authenticated by cookie and pass.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("Authorization") != "" || c.GetHeader("X-API-Key") != "" || !hasTokenCookie(c) {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(constants.CSRFCookie)
+		header := c.GetHeader(constants.CSRFHeader)
+		if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgInvalidCSRFToken,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func hasTokenCookie(c *gin.Context) bool {
+	for _, name := range []string{constants.AccessTokenCookie, constants.RefreshTokenCookie} {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
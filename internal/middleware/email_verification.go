@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// VerifiedEmailOnly turns away the restricted access tokens given to users
+// who haven't verified their email. It must run after
+// JWTAuthMiddleware.AuthRequired.
+func VerifiedEmailOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := auth.FromContext(c); ok && principal.Unverified {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgEmailNotVerified,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
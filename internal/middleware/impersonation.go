@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+type ImpersonationMiddleware struct {
+	impersonationService service.ImpersonationService
+	log                  *log.Logger
+}
+
+func NewImpersonationMiddleware(is service.ImpersonationService) *ImpersonationMiddleware {
+	return &ImpersonationMiddleware{
+		impersonationService: is,
+		log:                  log.Default(),
+	}
+}
+
+// Audit records every request made with an impersonation token, once it is
+// answered. It must run after JWTAuthMiddleware.AuthRequired.
+func (m *ImpersonationMiddleware) Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c)
+		if !ok || !principal.Impersonated() {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		entry := &entity.ImpersonationAudit{
+			ActorID: principal.ActorID,
+			UserID:  principal.UserID,
+			TokenID: principal.TokenID,
+			Method:  c.Request.Method,
+			Path:    c.Request.URL.Path,
+			Status:  c.Writer.Status(),
+			IP:      c.ClientIP(),
+		}
+
+		// Recorded even when the client is gone before the answer
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := m.impersonationService.Record(ctx, entry); err != nil {
+			m.log.Printf("IMPERSONATION AUDIT: failed to record %s %s (actor=%s user=%s): %s",
+				entry.Method, entry.Path, entry.ActorID, entry.UserID, err.Error())
+		}
+	}
+}
+
+// NoImpersonation keeps impersonation tokens away from routes that change
+// how the user logs in or that outlive the token. It must run after
+// JWTAuthMiddleware.AuthRequired.
+func NoImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := auth.FromContext(c); ok && principal.Impersonated() {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgImpersonating,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+type RBACMiddleware struct {
+	rbacService service.RBACService
+}
+
+func NewRBACMiddleware(rs service.RBACService) *RBACMiddleware {
+	return &RBACMiddleware{
+		rbacService: rs,
+	}
+}
+
+// RequirePermission lets through requests whose roles grant the permission.
+// API keys also need the admin scope. It must run after
+// JWTAuthMiddleware.AuthRequired.
+func (m *RBACMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgInvalidToken,
+			})
+			c.Abort()
+			return
+		}
+
+		// A key made for scripts doesn't carry its owner's admin powers unasked
+		if principal.AuthMethod == auth.MethodAPIKey && !principal.HasScope(entity.APIKeyScopeAdmin) {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgForbidden,
+			})
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		roles := principal.Roles
+		var err error
+		if principal.AuthMethod == auth.MethodAPIKey {
+			// API keys outlive role changes, they always get the current roles
+			roles, err = m.rbacService.UserRoles(ctx, principal.UserID)
+		}
+
+		var allowed bool
+		if err == nil {
+			allowed, err = m.rbacService.HasPermission(ctx, roles, permission)
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponseDTO{
+				Message: err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{
+				Message: constants.ErrMsgForbidden,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
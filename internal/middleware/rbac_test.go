@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/domain/entity"
+	"github.com/leonardonicola/golerplate/internal/domain/service"
+	"github.com/leonardonicola/golerplate/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminRoles grants every permission to the admin role, users are admins.
+type adminRoles struct {
+	service.RBACService
+}
+
+func (adminRoles) UserRoles(ctx context.Context, userID string) ([]string, error) {
+	return []string{entity.RoleAdmin}, nil
+}
+
+func (adminRoles) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	return slices.Contains(roles, entity.RoleAdmin), nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(principal *auth.Principal) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/admin/users", func(c *gin.Context) {
+			c.Set(auth.PrincipalKey, principal)
+		}, middleware.NewRBACMiddleware(adminRoles{}).RequirePermission(entity.PermUsersRead), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+		return w
+	}
+
+	t.Run("Roles of the token grant the permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(&auth.Principal{UserID: "user-id", Roles: []string{entity.RoleAdmin}}).Code)
+		assert.Equal(t, http.StatusForbidden, serve(&auth.Principal{UserID: "user-id"}).Code)
+	})
+
+	t.Run("An admin's API key needs the admin scope", func(t *testing.T) {
+		w := serve(&auth.Principal{UserID: "user-id", AuthMethod: auth.MethodAPIKey, Scopes: []string{entity.APIKeyScopeRead}})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = serve(&auth.Principal{UserID: "user-id", AuthMethod: auth.MethodAPIKey, Scopes: []string{entity.APIKeyScopeRead, entity.APIKeyScopeAdmin}})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/dto"
+	"github.com/leonardonicola/golerplate/pkg/constants"
+)
+
+// RequireRecentAuth lets through users who proved who they are within
+// maxAge, by logging in or at /reauth. Others get 401 with the
+// reauth_required error and the WWW-Authenticate challenge of RFC 9470. API
+// keys and impersonation tokens carry no auth time and never pass. It must
+// run after JWTAuthMiddleware.AuthRequired.
+func RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	seconds := int(maxAge.Seconds())
+
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c)
+		if ok && !principal.AuthTime.IsZero() && time.Since(principal.AuthTime) <= maxAge {
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description=%q, max_age=%d`,
+			constants.ErrMsgReauthRequired, seconds))
+		c.JSON(http.StatusUnauthorized, dto.ReauthRequiredDTO{
+			Message: constants.ErrMsgReauthRequired,
+			Error:   "reauth_required",
+			MaxAge:  seconds,
+		})
+		c.Abort()
+	}
+}
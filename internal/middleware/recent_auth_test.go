@@ -0,0 +1,49 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leonardonicola/golerplate/internal/domain/auth"
+	"github.com/leonardonicola/golerplate/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(principal *auth.Principal) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.POST("/password/change", func(c *gin.Context) {
+			c.Set(auth.PrincipalKey, principal)
+		}, middleware.RequireRecentAuth(5*time.Minute), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/password/change", nil))
+		return w
+	}
+
+	t.Run("A recent login passes", func(t *testing.T) {
+		w := serve(&auth.Principal{UserID: "user-id", AuthTime: time.Now().Add(-time.Minute)})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("An old login or none asks to re-authenticate", func(t *testing.T) {
+		for _, principal := range []*auth.Principal{
+			{UserID: "user-id", AuthTime: time.Now().Add(-time.Hour)},
+			{UserID: "user-id", AuthMethod: auth.MethodAPIKey},
+		} {
+			w := serve(principal)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.JSONEq(t, `{"message":"recent authentication required, re-authenticate at /api/reauth","error":"reauth_required","max_age":300}`, w.Body.String())
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), "max_age=300")
+		}
+	})
+}
@@ -10,6 +10,7 @@ const (
 	ErrMsgInvalidCPF         = "invalid CPF"
 	ErrMsgInvalidAge         = "invalid age: must be between 0 and 150"
 	ErrMsgInvalidName        = "invalid name: must be at least 2 characters"
+	ErrMsgAccountDisabled    = "this account is disabled"
 )
 
 // Auth
@@ -17,6 +18,112 @@ const (
 	ErrMsgMissingHeader    = "missing authorization header"
 	ErrMsgInvalidToken     = "invalid or expired token"
 	ErrMsgInvalidTokenType = "invalid token type"
+	ErrMsgTokenReused      = "refresh token reuse detected, session revoked"
+)
+
+// Password reset
+const (
+	ErrMsgInvalidResetToken = "invalid or expired password reset token"
+)
+
+// Email verification
+const (
+	ErrMsgInvalidVerificationToken = "invalid or expired email verification token"
+	ErrMsgEmailNotVerified         = "email address is not verified"
+)
+
+// Magic link
+const (
+	ErrMsgInvalidMagicLink = "invalid or expired login link"
+)
+
+// Login throttle
+const (
+	ErrMsgTooManyAttempts = "too many failed login attempts, try again later"
+)
+
+// Session
+const (
+	ErrMsgSessionNotFound = "session not found"
+	ErrMsgForbidden       = "you are not allowed to perform this action"
+)
+
+// MFA
+const (
+	ErrMsgMFANotEnrolled    = "two-factor authentication is not enabled"
+	ErrMsgMFAAlreadyEnabled = "two-factor authentication is already enabled"
+	ErrMsgInvalidMFACode    = "invalid two-factor code"
+)
+
+// Passkey
+const (
+	ErrMsgPasskeyNotFound       = "passkey not found"
+	ErrMsgInvalidPasskey        = "invalid passkey"
+	ErrMsgInvalidPasskeySession = "invalid or expired passkey ceremony"
+)
+
+// RBAC
+const (
+	ErrMsgRoleNotFound = "role not found"
+)
+
+// API key
+const (
+	ErrMsgAPIKeyNotFound    = "API key not found"
+	ErrMsgInvalidAPIKey     = "invalid, expired or revoked API key"
+	ErrMsgInsufficientScope = "API key scopes do not allow this request"
+)
+
+// OAuth
+const (
+	ErrMsgOAuthClientNotFound     = "OAuth client not found"
+	ErrMsgInvalidClient           = "invalid client credentials"
+	ErrMsgInvalidRedirectURI      = "unknown client or redirect URI"
+	ErrMsgUnsupportedResponseType = "response_type must be code"
+	ErrMsgPKCERequired            = "code_challenge with code_challenge_method S256 is required"
+	ErrMsgInvalidScope            = "scope is not allowed for this client"
+	ErrMsgAccessDenied            = "the user denied the authorization request"
+	ErrMsgInvalidGrant            = "invalid, expired or used authorization grant"
+	ErrMsgUnsupportedGrantType    = "unsupported grant_type"
+	ErrMsgUnauthorizedClient      = "this client is not allowed to use this grant type"
+	ErrMsgOpenIDScopeRequired     = "the token wasn't granted the openid scope"
+	ErrMsgOpenIDUnavailable       = "OpenID Connect needs an asymmetric access token key"
+)
+
+// Federated login
+const (
+	ErrMsgUnknownProvider          = "unknown identity provider"
+	ErrMsgInvalidFederatedLogin    = "invalid or expired federated login"
+	ErrMsgProviderEmailNotVerified = "the identity provider didn't verify the email"
+	ErrMsgIdentityNotLinked        = "an unverified account uses this email, verify it and link the identity from the account"
+	ErrMsgIdentityInUse            = "this identity is linked to another account"
+	ErrMsgIdentityNotFound         = "identity not found"
+)
+
+// Impersonation
+const (
+	ErrMsgImpersonating         = "not allowed while impersonating a user"
+	ErrMsgCannotImpersonateSelf = "you can't impersonate yourself"
+)
+
+// Cookie mode
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFCookie         = "csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+
+	ErrMsgInvalidCSRFToken = "missing or invalid CSRF token"
+)
+
+// Step-up authentication
+const (
+	ErrMsgReauthRequired = "recent authentication required, re-authenticate at /api/reauth"
+)
+
+// Security events
+const (
+	ErrMsgInvalidCursor = "invalid cursor"
 )
 
 const PORT = ":3000"
@@ -1,17 +1,196 @@
 package util
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-// The higher the salt, the more it takes to hash
-// e.g: From 50ms (10) to 400ms (13)
-const bcryptSalt = 10
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptSalt)
+// ErrUnsupportedHash is returned by a hasher asked to verify a hash made by
+// another algorithm.
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Password hash algorithms, as told apart by HashAlgorithm.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// HashAlgorithm names the algorithm of a stored hash, empty when unknown.
+// argon2id hashes are PHC strings, "$argon2id$v=19$m=...", while bcrypt ones
+// keep their native "$2a$<cost>$..." form, or "$2b$" and "$2y$" when imported
+// from other implementations. Both are stored as they are, never wrapped, so
+// this prefix is what picks the hasher and the upgrade on login.
+func HashAlgorithm(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return AlgorithmBcrypt
+	default:
+		return ""
+	}
+}
+
+// PasswordHasher hashes passwords into strings HashAlgorithm recognises.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash and, when it does,
+	// whether hash should be replaced by a fresh Hash because it was made
+	// with another algorithm or other parameters.
+	Verify(password, hash string) (match, rehash bool, err error)
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher hashes with bcrypt, the cost doubles the time it takes
+// with each step, e.g: from 50ms (10) to 400ms (13).
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{
+		cost: cost,
+	}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	return string(bytes), err
 }
 
-func CheckPasswordEquality(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+func (h *bcryptHasher) Verify(password, hash string) (bool, bool, error) {
+	if HashAlgorithm(hash) != AlgorithmBcrypt {
+		return false, false, ErrUnsupportedHash
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false, nil
+	}
+
+	return true, cost != h.cost, nil
+}
+
+// Argon2idParams are the argon2id costs, Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the OWASP minimum recommendation.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) PasswordHasher {
+	return &argon2idHasher{
+		params: params,
+	}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Verify(password, hash string) (bool, bool, error) {
+	parts := strings.Split(hash, "$")
+	if HashAlgorithm(hash) != AlgorithmArgon2id || len(parts) != 6 {
+		return false, false, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnsupportedHash
+	}
+
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, params != h.params, nil
+}
+
+type upgradingHasher struct {
+	current PasswordHasher
+	legacy  []PasswordHasher
+}
+
+// NewUpgradingHasher hashes with current and still verifies the hashes of
+// the legacy hashers, asking for a rehash whenever one of them matches.
+func NewUpgradingHasher(current PasswordHasher, legacy ...PasswordHasher) PasswordHasher {
+	return &upgradingHasher{
+		current: current,
+		legacy:  legacy,
+	}
+}
+
+func (h *upgradingHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+func (h *upgradingHasher) Verify(password, hash string) (bool, bool, error) {
+	match, rehash, err := h.current.Verify(password, hash)
+	if !errors.Is(err, ErrUnsupportedHash) {
+		return match, rehash, err
+	}
+
+	for _, legacy := range h.legacy {
+		match, _, err := legacy.Verify(password, hash)
+		if errors.Is(err, ErrUnsupportedHash) {
+			continue
+		}
+
+		return match, match, err
+	}
+
+	return false, false, ErrUnsupportedHash
 }
@@ -0,0 +1,71 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leonardonicola/golerplate/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Small costs keep the tests fast
+var params = util.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := util.NewArgon2idHasher(params)
+
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+
+	match, rehash, err := hasher.Verify("password123", hash)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.False(t, rehash)
+
+	match, _, err = hasher.Verify("wrong", hash)
+	require.NoError(t, err)
+	assert.False(t, match)
+
+	// Stronger parameters ask for a rehash
+	stronger := params
+	stronger.Iterations = 2
+	match, rehash, err = util.NewArgon2idHasher(stronger).Verify("password123", hash)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, rehash)
+}
+
+func TestUpgradingHasher(t *testing.T) {
+	legacy := util.NewBcryptHasher(bcrypt.MinCost)
+	hasher := util.NewUpgradingHasher(util.NewArgon2idHasher(params), legacy)
+
+	bcryptHash, err := legacy.Hash("password123")
+	require.NoError(t, err)
+
+	match, rehash, err := hasher.Verify("password123", bcryptHash)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, rehash)
+
+	match, rehash, err = hasher.Verify("wrong", bcryptHash)
+	require.NoError(t, err)
+	assert.False(t, match)
+	assert.False(t, rehash)
+
+	_, _, err = hasher.Verify("password123", "plaintext")
+	assert.ErrorIs(t, err, util.ErrUnsupportedHash)
+}
+
+func TestHashAlgorithm(t *testing.T) {
+	bcryptHash, err := util.NewBcryptHasher(bcrypt.MinCost).Hash("password123")
+	require.NoError(t, err)
+	argon2idHash, err := util.NewArgon2idHasher(params).Hash("password123")
+	require.NoError(t, err)
+
+	assert.Equal(t, util.AlgorithmBcrypt, util.HashAlgorithm(bcryptHash))
+	assert.Equal(t, util.AlgorithmArgon2id, util.HashAlgorithm(argon2idHash))
+	assert.Empty(t, util.HashAlgorithm("plaintext"))
+}
@@ -0,0 +1,267 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy are the rules every new password must follow.
+type PasswordPolicy struct {
+	// MinLength is counted in characters.
+	MinLength int
+	// MaxLength is counted in bytes, bcrypt ignores anything past 72.
+	MaxLength int
+	// MinClasses is how many of lowercase, uppercase, digits and symbols
+	// must appear.
+	MinClasses int
+	// Breached is checked last, nil skips the check.
+	Breached BreachedPasswords
+}
+
+// DefaultPasswordPolicy asks for length rather than composition.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MaxLength:  72,
+	MinClasses: 1,
+}
+
+var nonDigits = regexp.MustCompile(`[^0-9]`)
+
+// Check returns ValidationErrors listing the broken rules, nil when the
+// password is fine. personal are the user's name, email and CPF, which the
+// password must not contain. Other errors come from the breached list.
+func (p *PasswordPolicy) Check(password string, personal ...string) error {
+	var violations ValidationErrors
+	violation := func(format string, args ...any) {
+		violations = append(violations, ValidationError{Field: "password", Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violation("Minimum length is %d", p.MinLength)
+	}
+
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violation("Maximum length is %d bytes", p.MaxLength)
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violation("Must mix at least %d of lowercase, uppercase, digits and symbols", p.MinClasses)
+	}
+
+	if containsPersonal(password, personal) {
+		violation("Must not contain your name, email or CPF")
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			violation("This password appeared in a data breach, choose another one")
+			return violations
+		}
+	}
+
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// containsPersonal looks for each word of the values, the local part of
+// emails and the digits of CPFs. Parts under 3 characters are too common to
+// tell anything.
+func containsPersonal(password string, personal []string) bool {
+	lowered := strings.ToLower(password)
+
+	var parts []string
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if local, _, found := strings.Cut(value, "@"); found {
+			parts = append(parts, local)
+			continue
+		}
+
+		if digits := nonDigits.ReplaceAllString(value, ""); len(digits) == 11 {
+			parts = append(parts, digits)
+		}
+
+		parts = append(parts, strings.Fields(value)...)
+	}
+
+	for _, part := range parts {
+		if len(part) >= 3 && strings.Contains(lowered, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type BreachedPasswords interface {
+	Contains(password string) (bool, error)
+}
+
+type breachedPasswordFile struct {
+	path string
+	size int64
+}
+
+// OpenBreachedPasswords reads the SHA-1 lists of Have I Been Pwned from disk,
+// never loading them in memory. path is either the "ordered by hash" file,
+// one "HASH:COUNT" line per password, or a directory of range files as
+// fetched by the downloader, "ABCDE.txt" holding "SUFFIX:COUNT" lines for the
+// hashes starting with ABCDE.
+func OpenBreachedPasswords(path string) (BreachedPasswords, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return breachedPasswordRanges(path), nil
+	}
+
+	return &breachedPasswordFile{
+		path: path,
+		size: info.Size(),
+	}, nil
+}
+
+func (b *breachedPasswordFile) Contains(password string) (bool, error) {
+	target := []byte(sha1Hex(password))
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	// Invariant: the line of target, if any, starts in [lo, hi)
+	lo, hi := int64(0), b.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := lineFrom(f, mid)
+		if err != nil {
+			return false, err
+		}
+
+		if line == nil {
+			hi = mid
+			continue
+		}
+
+		hash, _, _ := bytes.Cut(bytes.TrimSpace(line), []byte(":"))
+		switch bytes.Compare(bytes.ToUpper(hash), target) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = start + int64(len(line))
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+type breachedPasswordRanges string
+
+func (dir breachedPasswordRanges) Contains(password string) (bool, error) {
+	hash := sha1Hex(password)
+
+	f, err := os.Open(filepath.Join(string(dir), hash[:5]+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		suffix, _, _ := strings.Cut(scanner.Text(), ":")
+		if strings.EqualFold(strings.TrimSpace(suffix), hash[5:]) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// lineFrom returns the first line starting at or after offset and where it
+// starts, newline included, or nil at the end of the file.
+func lineFrom(f *os.File, offset int64) (int64, []byte, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return 0, nil, err
+	}
+
+	reader := bufio.NewReader(f)
+
+	if offset > 0 {
+		// Skip to the end of the line offset falls in, unless offset-1 is
+		// already a newline
+		skipped, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return 0, nil, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		return 0, nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+
+	return start, line, nil
+}
@@ -0,0 +1,133 @@
+package util_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/leonardonicola/golerplate/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// messages returns the messages of the policy violations in err.
+func messages(t *testing.T, err error) []string {
+	var violations util.ValidationErrors
+	require.ErrorAs(t, err, &violations)
+
+	var got []string
+	for _, v := range violations {
+		assert.Equal(t, "password", v.Field)
+		got = append(got, v.Message)
+	}
+	return got
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := util.PasswordPolicy{MinLength: 10, MaxLength: 72, MinClasses: 3}
+
+	assert.NoError(t, policy.Check("correct Horse 9"))
+
+	err := policy.Check("short")
+	assert.ElementsMatch(t, []string{
+		"Minimum length is 10",
+		"Must mix at least 3 of lowercase, uppercase, digits and symbols",
+	}, messages(t, err))
+
+	// The maximum is in bytes, the minimum in characters
+	assert.NoError(t, policy.Check("çççççççççç1A"))
+	assert.Equal(t, []string{"Maximum length is 72 bytes"}, messages(t, policy.Check(strings.Repeat("aA1", 25))))
+}
+
+func TestPasswordPolicyPersonalData(t *testing.T) {
+	policy := util.DefaultPasswordPolicy
+	personal := []string{"Maria Souza", "msouza@example.com", "152.459.018-54"}
+
+	for _, password := range []string{"souza-rocks", "MARIA2024!", "hi-msouza-hi", "x15245901854"} {
+		assert.Equal(t, []string{"Must not contain your name, email or CPF"}, messages(t, policy.Check(password, personal...)), password)
+	}
+
+	// The domain of the email and short parts tell nothing
+	assert.NoError(t, policy.Check("example-of-a-passphrase", personal...))
+	assert.NoError(t, policy.Check("long enough passphrase", "Jo Li"))
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	breached := []string{"password", "123456", "qwerty", "letmein", "dragon"}
+
+	t.Run("File ordered by hash", func(t *testing.T) {
+		var lines []string
+		for i, password := range breached {
+			lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), i+1))
+		}
+		// Padding so the search has to cross several lines
+		for i := 0; i < 200; i++ {
+			lines = append(lines, fmt.Sprintf("%s:1", sha1Hex(fmt.Sprintf("filler-%d", i))))
+		}
+		sort.Strings(lines)
+
+		path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600))
+
+		list, err := util.OpenBreachedPasswords(path)
+		require.NoError(t, err)
+
+		for _, password := range append(breached, "filler-0", "filler-199") {
+			found, err := list.Contains(password)
+			require.NoError(t, err)
+			assert.True(t, found, password)
+		}
+
+		found, err := list.Contains("correct horse battery staple")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("Directory of range files", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, password := range breached {
+			hash := sha1Hex(password)
+			f, err := os.OpenFile(filepath.Join(dir, hash[:5]+".txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			require.NoError(t, err)
+			_, err = fmt.Fprintf(f, "%s:3\r\n", hash[5:])
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}
+
+		list, err := util.OpenBreachedPasswords(dir)
+		require.NoError(t, err)
+
+		found, err := list.Contains("letmein")
+		require.NoError(t, err)
+		assert.True(t, found)
+
+		found, err = list.Contains("correct horse battery staple")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("The policy refuses breached passwords", func(t *testing.T) {
+		dir := t.TempDir()
+		hash := sha1Hex("password123")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(hash[5:]+":9\n"), 0o600))
+
+		list, err := util.OpenBreachedPasswords(dir)
+		require.NoError(t, err)
+
+		policy := util.DefaultPasswordPolicy
+		policy.Breached = list
+
+		assert.Equal(t, []string{"This password appeared in a data breach, choose another one"}, messages(t, policy.Check("password123")))
+		assert.NoError(t, policy.Check("password1234"))
+	})
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -16,8 +17,26 @@ type ValidationError struct {
 	Message string `json:"message"`
 }
 
+// ValidationErrors are the failures of checks made past request binding, such
+// as the password policy.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = fmt.Sprintf("%s: %s", err.Field, err.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
 // HandleValidationError formats validation errors into a consistent response
 func HandleValidationError(err error) *ValidationResponse {
+	if errs, ok := err.(ValidationErrors); ok {
+		return &ValidationResponse{
+			Errors: errs,
+		}
+	}
+
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
 		var errors []ValidationError
 